@@ -135,16 +135,52 @@ func ConnectDatabase() {
 		fmt.Println("✅ PIN column added and populated for existing users")
 	}
 
+	// Backfill new expense columns before AutoMigrate enforces constraints
+	migrateExpenseSpentAt(database)
+	migrateMoneyColumns(database)
+	migrateExpenseBaseAmounts(database)
+	needsMerchantBackfill := tableExists(database, "expenses") && !columnExists(database, "expenses", "merchant_id")
+
 	// Auto-migrate all models
 	err = database.AutoMigrate(
 		&models.User{},
 		&models.OTPVerification{},
 		&models.Expense{},
+		&models.ExpenseItem{},
+		&models.ExpenseRevision{},
+		&models.BulkOperation{},
+		&models.BulkOperationEntry{},
 		&models.Budget{},
+		&models.ExchangeRate{},
+		&models.Category{},
+		&models.Tag{},
+		&models.Account{},
+		&models.Transfer{},
+		&models.Income{},
+		&models.Claim{},
+		&models.RecurringIncome{},
+		&models.RecurringExpense{},
+		&models.Receipt{},
+		&models.ImportMapping{},
+		&models.ImportBatch{},
+		&models.CategoryRule{},
+		&models.CategoryCorrection{},
+		&models.Merchant{},
+		&models.MerchantAlias{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	migrateCategoryLinks(database)
+	if needsMerchantBackfill {
+		backfillExpenseMerchants(database)
+	}
+	runBackfillOnce(database, "trash_deactivated_budgets", trashDeactivatedBudgets)
+	if err := utils.EnsureDefaultRules(database); err != nil {
+		log.Printf("⚠️  Could not seed default categorization rules: %v", err)
+	}
+	loadExchangeRatesFile(database)
+
 	fmt.Println("✅ Database migration completed successfully!")
 }
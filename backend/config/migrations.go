@@ -0,0 +1,273 @@
+package config
+
+import (
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// tableExists reports whether a table is present in the current database
+func tableExists(database *gorm.DB, table string) bool {
+	var count int64
+	database.Raw("SELECT COUNT(*) FROM information_schema.tables WHERE table_name = ?", table).Scan(&count)
+	return count > 0
+}
+
+// columnExists reports whether a column is present on the given table
+func columnExists(database *gorm.DB, table, column string) bool {
+	var count int64
+	database.Raw("SELECT COUNT(*) FROM information_schema.columns WHERE table_name = ? AND column_name = ?",
+		table, column).Scan(&count)
+	return count > 0
+}
+
+// migrateExpenseSpentAt adds the spent_at column to existing expenses and
+// backfills it from created_at so historical spending keeps its dates
+func migrateExpenseSpentAt(database *gorm.DB) {
+	if !tableExists(database, "expenses") || columnExists(database, "expenses", "spent_at") {
+		return
+	}
+
+	fmt.Println("🔧 Adding spent_at column to expenses...")
+
+	if err := database.Exec("ALTER TABLE expenses ADD COLUMN spent_at timestamptz").Error; err != nil {
+		log.Printf("Error adding spent_at column: %v", err)
+		return
+	}
+
+	result := database.Exec("UPDATE expenses SET spent_at = created_at WHERE spent_at IS NULL")
+	if result.Error != nil {
+		log.Printf("Error backfilling spent_at: %v", result.Error)
+		return
+	}
+
+	database.Exec("ALTER TABLE expenses ALTER COLUMN spent_at SET NOT NULL")
+
+	fmt.Printf("✅ Backfilled spent_at for %d expenses\n", result.RowsAffected)
+}
+
+// migrateMoneyColumns converts float amount columns into integer minor units.
+// Values are rounded to the nearest paisa exactly once, in the database.
+func migrateMoneyColumns(database *gorm.DB) {
+	for _, table := range []string{"expenses", "budgets"} {
+		if !tableExists(database, table) {
+			continue
+		}
+
+		var dataType string
+		database.Raw("SELECT data_type FROM information_schema.columns WHERE table_name = ? AND column_name = 'amount'",
+			table).Scan(&dataType)
+		if dataType != "double precision" && dataType != "real" && dataType != "numeric" {
+			continue
+		}
+
+		fmt.Printf("🔧 Converting %s.amount to minor units...\n", table)
+
+		err := database.Exec(fmt.Sprintf(
+			"ALTER TABLE %s ALTER COLUMN amount TYPE bigint USING ROUND(COALESCE(amount, 0) * 100)::bigint", table)).Error
+		if err != nil {
+			log.Printf("Error converting %s.amount: %v", table, err)
+			continue
+		}
+
+		fmt.Printf("✅ %s.amount now stored in minor units\n", table)
+	}
+}
+
+// migrateExpenseBaseAmounts seeds the converted-amount columns for expenses
+// recorded before multi-currency support; those were all in INR
+func migrateExpenseBaseAmounts(database *gorm.DB) {
+	if !tableExists(database, "expenses") || columnExists(database, "expenses", "base_amount") {
+		return
+	}
+
+	fmt.Println("🔧 Adding base currency columns to expenses...")
+
+	database.Exec("ALTER TABLE expenses ADD COLUMN base_amount bigint")
+	database.Exec("ALTER TABLE expenses ADD COLUMN IF NOT EXISTS base_currency varchar(3) NOT NULL DEFAULT 'INR'")
+	database.Exec("ALTER TABLE expenses ADD COLUMN IF NOT EXISTS exchange_rate numeric(20,10) NOT NULL DEFAULT 1")
+
+	result := database.Exec("UPDATE expenses SET base_amount = amount WHERE base_amount IS NULL")
+	if result.Error != nil {
+		log.Printf("Error backfilling base_amount: %v", result.Error)
+		return
+	}
+
+	database.Exec("ALTER TABLE expenses ALTER COLUMN base_amount SET NOT NULL")
+
+	fmt.Printf("✅ Backfilled base amounts for %d expenses\n", result.RowsAffected)
+}
+
+// loadExchangeRatesFile imports rates from EXCHANGE_RATES_FILE (CSV or JSON) at startup
+func loadExchangeRatesFile(database *gorm.DB) {
+	path := os.Getenv("EXCHANGE_RATES_FILE")
+	if path == "" {
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("⚠️  Could not open exchange rates file %s: %v", path, err)
+		return
+	}
+	defer file.Close()
+
+	var inputs []utils.ExchangeRateInput
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		inputs, err = utils.ParseExchangeRatesJSON(file)
+	} else {
+		inputs, err = utils.ParseExchangeRatesCSV(file)
+	}
+	if err != nil {
+		log.Printf("⚠️  Could not parse exchange rates file %s: %v", path, err)
+		return
+	}
+
+	saved, updated, err := utils.SaveExchangeRates(database, inputs, "file")
+	if err != nil {
+		log.Printf("⚠️  Could not load exchange rates: %v", err)
+		return
+	}
+
+	fmt.Printf("✅ Loaded %d exchange rates from %s (%d expenses re-converted)\n", saved, path, updated)
+}
+
+// normalizedCategorySQL mirrors models.NormalizeCategoryName inside Postgres
+const normalizedCategorySQL = "lower(trim(regexp_replace(%s, '\\s+', ' ', 'g')))"
+
+// migrateCategoryLinks links free-text categories on existing expenses and
+// budgets to category records, creating per-user categories for names that
+// don't match a default
+func migrateCategoryLinks(database *gorm.DB) {
+	var userIDs []uint
+	database.Raw(`SELECT DISTINCT user_id FROM expenses WHERE category_id IS NULL AND trim(category) <> ''
+		UNION SELECT DISTINCT user_id FROM budgets WHERE category_id IS NULL AND trim(category) <> ''`).Scan(&userIDs)
+	if len(userIDs) == 0 {
+		return
+	}
+
+	fmt.Printf("🔧 Linking categories for %d users...\n", len(userIDs))
+
+	normalizedExpense := fmt.Sprintf(normalizedCategorySQL, "category")
+	for _, userID := range userIDs {
+		if err := utils.EnsureDefaultCategories(database, userID); err != nil {
+			log.Printf("Error seeding categories for user %d: %v", userID, err)
+			continue
+		}
+
+		type namePair struct {
+			Name       string
+			Normalized string
+		}
+		var names []namePair
+		database.Raw(fmt.Sprintf(`SELECT DISTINCT ON (normalized) trim(category) AS name, normalized FROM (
+				SELECT category, %s AS normalized FROM expenses WHERE user_id = ? AND category_id IS NULL
+				UNION ALL
+				SELECT category, %s AS normalized FROM budgets WHERE user_id = ? AND category_id IS NULL
+			) names WHERE normalized <> '' ORDER BY normalized, name`, normalizedExpense, normalizedExpense),
+			userID, userID).Scan(&names)
+
+		for _, name := range names {
+			var existing int64
+			database.Model(&models.Category{}).Where("user_id = ? AND normalized_name = ?", userID, name.Normalized).Count(&existing)
+			if existing > 0 {
+				continue
+			}
+			database.Create(&models.Category{
+				UserID:         userID,
+				Name:           strings.Join(strings.Fields(name.Name), " "),
+				NormalizedName: name.Normalized,
+			})
+		}
+	}
+
+	for _, table := range []string{"expenses", "budgets"} {
+		err := database.Exec(fmt.Sprintf(`UPDATE %s SET category_id = c.id, category = c.name FROM categories c
+			WHERE c.user_id = %s.user_id AND c.deleted_at IS NULL AND %s.category_id IS NULL
+			AND c.normalized_name = %s`, table, table, table, fmt.Sprintf(normalizedCategorySQL, table+".category"))).Error
+		if err != nil {
+			log.Printf("Error linking %s to categories: %v", table, err)
+		}
+	}
+
+	fmt.Println("✅ Categories linked")
+}
+
+// backfillExpenseMerchants links existing expenses to merchants derived from
+// their titles. It runs once, when the merchant_id column is first added.
+func backfillExpenseMerchants(database *gorm.DB) {
+	fmt.Println("🔧 Linking existing expenses to merchants...")
+
+	var linked int
+	var expenses []models.Expense
+	result := database.Unscoped().Select("id, user_id, title").Where("merchant_id IS NULL").
+		FindInBatches(&expenses, 500, func(tx *gorm.DB, batch int) error {
+			for _, expense := range expenses {
+				merchant, err := utils.ResolveMerchant(database, expense.UserID, expense.Title, "")
+				if err != nil {
+					return err
+				}
+				if merchant == nil {
+					continue
+				}
+				if err := database.Unscoped().Model(&models.Expense{}).Where("id = ?", expense.ID).
+					Update("merchant_id", merchant.ID).Error; err != nil {
+					return err
+				}
+				linked++
+			}
+			return nil
+		})
+	if result.Error != nil {
+		log.Printf("Error linking expenses to merchants: %v", result.Error)
+		return
+	}
+
+	fmt.Printf("✅ Linked %d expenses to merchants\n", linked)
+}
+
+// runBackfillOnce runs a data backfill that has no schema change to key off,
+// recording its name so it is skipped on later starts
+func runBackfillOnce(database *gorm.DB, name string, backfill func(*gorm.DB) error) {
+	if err := database.Exec("CREATE TABLE IF NOT EXISTS schema_backfills (name text PRIMARY KEY, applied_at timestamptz NOT NULL DEFAULT now())").Error; err != nil {
+		log.Printf("Error creating schema_backfills: %v", err)
+		return
+	}
+
+	var count int64
+	database.Raw("SELECT COUNT(*) FROM schema_backfills WHERE name = ?", name).Scan(&count)
+	if count > 0 {
+		return
+	}
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if err := backfill(tx); err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO schema_backfills (name) VALUES (?)", name).Error
+	})
+	if err != nil {
+		log.Printf("Error running backfill %s: %v", name, err)
+	}
+}
+
+// trashDeactivatedBudgets moves budgets deleted before the trash existed, which
+// were only switched off, into the trash so they can be restored or purged and
+// stop counting as in use. They come back active if restored.
+func trashDeactivatedBudgets(tx *gorm.DB) error {
+	result := tx.Exec(`UPDATE budgets SET deleted_at = updated_at, is_active = true, version = version + 1
+		WHERE is_active = false AND deleted_at IS NULL`)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		fmt.Printf("✅ Moved %d deactivated budgets to the trash\n", result.RowsAffected)
+	}
+	return nil
+}
@@ -0,0 +1,494 @@
+package controllers
+
+import (
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// isValidAccountType reports whether the type is one of the supported account types
+func isValidAccountType(accountType string) bool {
+	for _, valid := range models.ValidAccountTypes {
+		if accountType == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidNumberSuffix accepts the last 3-6 digits of an account or card number
+func isValidNumberSuffix(suffix string) bool {
+	if len(suffix) < 3 || len(suffix) > 6 {
+		return false
+	}
+	for _, r := range suffix {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// findActiveAccount loads one of the user's active accounts for linking a transaction
+func findActiveAccount(db *gorm.DB, userID uint, accountID uint) (*models.Account, error) {
+	var account models.Account
+	if err := db.Where("id = ? AND user_id = ? AND is_active = ?", accountID, userID, true).
+		First(&account).Error; err != nil {
+		return nil, errors.New("account not found")
+	}
+	return &account, nil
+}
+
+// assignExpenseAccount checks the expense's account belongs to the user and
+// defaults the expense currency to the account's currency
+func assignExpenseAccount(db *gorm.DB, userID uint, expense *models.Expense) error {
+	if expense.AccountID == nil || *expense.AccountID == 0 {
+		expense.AccountID = nil
+		return nil
+	}
+
+	account, err := findActiveAccount(db, userID, *expense.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if expense.Currency == "" {
+		expense.Currency = account.Currency
+	}
+	return nil
+}
+
+// amountInCurrency converts an amount into another currency at the given date
+func amountInCurrency(amount models.Money, from, to string, on time.Time) (models.Money, error) {
+	rate, err := utils.FindExchangeRate(config.DB, from, to, on)
+	if err != nil {
+		return 0, err
+	}
+	return utils.ConvertMoney(amount, rate), nil
+}
+
+// buildAccountLedger lists every movement on an account in date order with running balances
+func buildAccountLedger(account models.Account) ([]models.LedgerEntry, error) {
+	entries := []models.LedgerEntry{}
+
+	var expenses []models.Expense
+	config.DB.Where("account_id = ? AND user_id = ?", account.ID, account.UserID).Find(&expenses)
+	for _, expense := range expenses {
+		amount, err := amountInCurrency(expense.Amount, expense.Currency, account.Currency, expense.SpentAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, models.LedgerEntry{
+			Date:        expense.SpentAt,
+			Type:        "expense",
+			ReferenceID: expense.ID,
+			Description: expense.Title,
+			Amount:      -amount,
+		})
+	}
+
+	var incomes []models.Income
+	config.DB.Where("account_id = ? AND user_id = ?", account.ID, account.UserID).Find(&incomes)
+	for _, income := range incomes {
+		amount, err := amountInCurrency(income.Amount, income.Currency, account.Currency, income.ReceivedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, models.LedgerEntry{
+			Date:        income.ReceivedAt,
+			Type:        "income",
+			ReferenceID: income.ID,
+			Description: income.Title,
+			Amount:      amount,
+		})
+	}
+
+	var transfers []models.Transfer
+	config.DB.Where("user_id = ? AND (from_account_id = ? OR to_account_id = ?)", account.UserID, account.ID, account.ID).
+		Find(&transfers)
+	for _, transfer := range transfers {
+		if transfer.FromAccountID == account.ID {
+			entries = append(entries, models.LedgerEntry{
+				Date:        transfer.TransferredAt,
+				Type:        "transfer_out",
+				ReferenceID: transfer.ID,
+				Description: transfer.Note,
+				Amount:      -transfer.Amount,
+			})
+		}
+		if transfer.ToAccountID == account.ID {
+			entries = append(entries, models.LedgerEntry{
+				Date:        transfer.TransferredAt,
+				Type:        "transfer_in",
+				ReferenceID: transfer.ID,
+				Description: transfer.Note,
+				Amount:      transfer.ToAmount,
+			})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	opening := models.LedgerEntry{
+		Date:        account.OpeningDate,
+		Type:        "opening",
+		ReferenceID: account.ID,
+		Description: "Opening balance",
+		Amount:      account.OpeningBalance,
+		Balance:     account.OpeningBalance,
+	}
+
+	balance := account.OpeningBalance
+	for i := range entries {
+		balance += entries[i].Amount
+		entries[i].Balance = balance
+	}
+
+	return append([]models.LedgerEntry{opening}, entries...), nil
+}
+
+// accountBalance returns the account's current balance
+func accountBalance(account models.Account) (models.Money, error) {
+	ledger, err := buildAccountLedger(account)
+	if err != nil {
+		return 0, err
+	}
+	return ledger[len(ledger)-1].Balance, nil
+}
+
+// findUserAccount loads an account owned by the user, writing a 404 if missing
+func findUserAccount(c *gin.Context, userID uint, id interface{}) (*models.Account, bool) {
+	var account models.Account
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&account).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &account, true
+}
+
+// GetAccounts lists the user's accounts with current balances
+func GetAccounts(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if c.Query("include_inactive") != "true" {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var accounts []models.Account
+	query.Order("name").Find(&accounts)
+
+	accountsWithBalance := []models.AccountWithBalance{}
+	for _, account := range accounts {
+		balance, err := accountBalance(account)
+		if err != nil {
+			c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		accountsWithBalance = append(accountsWithBalance, models.AccountWithBalance{Account: account, Balance: balance})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accounts": accountsWithBalance})
+}
+
+// CreateAccount adds a cash wallet, bank account, credit card or UPI wallet
+func CreateAccount(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var account models.Account
+	if err := c.ShouldBindJSON(&account); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account.Name = strings.TrimSpace(account.Name)
+	if account.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account name is required"})
+		return
+	}
+	if account.Type == "" {
+		account.Type = models.AccountTypeCash
+	}
+	if !isValidAccountType(account.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account type", "valid_types": models.ValidAccountTypes})
+		return
+	}
+	if account.NumberSuffix != "" && !isValidNumberSuffix(account.NumberSuffix) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "number_suffix must be the last 3-6 digits of the account number"})
+		return
+	}
+
+	if account.Currency == "" {
+		account.Currency = getUserBaseCurrency(userID)
+	}
+	currency, err := utils.NormalizeCurrency(account.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account.ID = 0
+	account.UserID = userID
+	account.Currency = currency
+	account.IsActive = true
+	if account.OpeningDate.IsZero() {
+		account.OpeningDate = time.Now()
+	}
+
+	if err := config.DB.Create(&account).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"account": models.AccountWithBalance{Account: account, Balance: account.OpeningBalance}})
+}
+
+// GetAccountByID returns an account with its current balance
+func GetAccountByID(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	account, ok := findUserAccount(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	balance, err := accountBalance(*account)
+	if err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"account": models.AccountWithBalance{Account: *account, Balance: balance}})
+}
+
+// GetAccountLedger returns the account's transactions with a running balance
+func GetAccountLedger(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	account, ok := findUserAccount(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	ledger, err := buildAccountLedger(*account)
+	if err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"account": account,
+		"balance": ledger[len(ledger)-1].Balance,
+		"ledger":  ledger,
+	})
+}
+
+// UpdateAccount edits the fields sent of an account: name, type, number suffix
+// or opening balance and date
+func UpdateAccount(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	account, ok := findUserAccount(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var updateData models.UpdateAccountRequest
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if updateData.Name != nil {
+		name := strings.TrimSpace(*updateData.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Account name is required"})
+			return
+		}
+		account.Name = name
+	}
+	if updateData.Type != nil {
+		if !isValidAccountType(*updateData.Type) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account type", "valid_types": models.ValidAccountTypes})
+			return
+		}
+		account.Type = *updateData.Type
+	}
+	if updateData.NumberSuffix != nil {
+		if *updateData.NumberSuffix != "" && !isValidNumberSuffix(*updateData.NumberSuffix) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "number_suffix must be the last 3-6 digits of the account number"})
+			return
+		}
+		account.NumberSuffix = *updateData.NumberSuffix
+	}
+	if updateData.OpeningBalance != nil {
+		account.OpeningBalance = *updateData.OpeningBalance
+	}
+	if updateData.OpeningDate != nil && !updateData.OpeningDate.IsZero() {
+		account.OpeningDate = *updateData.OpeningDate
+	}
+
+	config.DB.Save(account)
+
+	balance, err := accountBalance(*account)
+	if err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"account": models.AccountWithBalance{Account: *account, Balance: balance}})
+}
+
+// DeleteAccount removes an unused account, or archives it when transactions reference it
+func DeleteAccount(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	account, ok := findUserAccount(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var expenseCount, incomeCount, transferCount int64
+	config.DB.Unscoped().Model(&models.Expense{}).Where("account_id = ?", account.ID).Count(&expenseCount)
+	config.DB.Unscoped().Model(&models.Income{}).Where("account_id = ?", account.ID).Count(&incomeCount)
+	config.DB.Model(&models.Transfer{}).Where("from_account_id = ? OR to_account_id = ?", account.ID, account.ID).Count(&transferCount)
+
+	if expenseCount > 0 || incomeCount > 0 || transferCount > 0 {
+		account.IsActive = false
+		config.DB.Save(account)
+		c.JSON(http.StatusOK, gin.H{"message": "Account has transactions and was archived instead"})
+		return
+	}
+
+	config.DB.Delete(account)
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
+
+// GetTransfers lists transfers between the user's accounts, newest first
+func GetTransfers(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if accountID := c.Query("account_id"); accountID != "" {
+		query = query.Where("from_account_id = ? OR to_account_id = ?", accountID, accountID)
+	}
+
+	var transfers []models.Transfer
+	query.Order("transferred_at DESC, id DESC").Find(&transfers)
+	c.JSON(http.StatusOK, gin.H{"transfers": transfers})
+}
+
+// CreateTransfer moves money between two accounts without recording spending
+func CreateTransfer(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var transfer models.Transfer
+	if err := c.ShouldBindJSON(&transfer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if transfer.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+		return
+	}
+	if transfer.FromAccountID == transfer.ToAccountID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Source and destination accounts must differ"})
+		return
+	}
+
+	var from, to models.Account
+	if config.DB.Where("id = ? AND user_id = ? AND is_active = ?", transfer.FromAccountID, userID, true).First(&from).Error != nil ||
+		config.DB.Where("id = ? AND user_id = ? AND is_active = ?", transfer.ToAccountID, userID, true).First(&to).Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account not found"})
+		return
+	}
+
+	if transfer.TransferredAt.IsZero() {
+		transfer.TransferredAt = time.Now()
+	}
+
+	// Cross-currency transfers use the client's received amount, or the stored rate
+	if from.Currency == to.Currency {
+		transfer.ToAmount = transfer.Amount
+	} else if transfer.ToAmount <= 0 {
+		toAmount, err := amountInCurrency(transfer.Amount, from.Currency, to.Currency, transfer.TransferredAt)
+		if err != nil {
+			c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		transfer.ToAmount = toAmount
+	}
+
+	transfer.ID = 0
+	transfer.UserID = userID
+	if err := config.DB.Create(&transfer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"transfer": transfer})
+}
+
+// DeleteTransfer removes a transfer, restoring both account balances
+func DeleteTransfer(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var transfer models.Transfer
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&transfer).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+		return
+	}
+
+	config.DB.Delete(&transfer)
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer deleted successfully"})
+}
@@ -1,13 +1,16 @@
 package controllers
 
 import (
+	"errors"
 	"finance-app-backend/config"
 	"finance-app-backend/models"
+	"finance-app-backend/utils"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Get user ID from JWT token
@@ -19,7 +22,114 @@ func getBudgetUserIDFromToken(c *gin.Context) (uint, error) {
 	return userID.(uint), nil
 }
 
-// CreateBudget creates a new budget for a category
+// calculateBudgetSpent sums the user's spending in the budget's category,
+// attributed by the date the money was spent rather than when it was logged.
+// Amounts are the base-currency conversions, which match the budget currency.
+func calculateBudgetSpent(userID uint, budget models.Budget) models.Money {
+	// Work expenses the user has been paid back for weren't really their spending
+	query := config.DB.Model(&models.Expense{}).
+		Where("expenses.user_id = ? AND expenses.base_currency = ? AND expenses.spent_at >= ? AND expenses.spent_at < ?",
+			userID, budget.Currency, budget.StartDate, budget.PeriodEnd()).
+		Where("expenses.reimbursement_status <> ?", models.ReimbursementReimbursed)
+
+	// A tag budget counts every expense carrying the tag, whatever its category
+	if budget.TagID != nil {
+		var totalSpent int64
+		query.Where("expenses.id IN (SELECT expense_id FROM expense_tags WHERE tag_id = ?)", *budget.TagID).
+			Select("COALESCE(SUM(expenses.base_amount), 0)").Row().Scan(&totalSpent)
+		return models.Money(totalSpent)
+	}
+
+	// A budget on a parent category includes spending in all its subcategories
+	categoryFilter, categoryArg := "category = ?", interface{}(budget.Category)
+	if budget.CategoryID != nil {
+		categoryIDs, err := utils.CategoryTreeIDs(config.DB, *budget.CategoryID)
+		if err != nil || len(categoryIDs) == 0 {
+			categoryIDs = []uint{*budget.CategoryID}
+		}
+		categoryFilter, categoryArg = "category_id IN ?", categoryIDs
+	}
+
+	// Itemized expenses count through their line items, so one supermarket
+	// bill can feed the groceries and household budgets separately
+	var wholeSpent, itemSpent int64
+	query.Session(&gorm.Session{}).
+		Where("expenses."+categoryFilter, categoryArg).
+		Where("NOT EXISTS (SELECT 1 FROM expense_items WHERE expense_items.expense_id = expenses.id)").
+		Select("COALESCE(SUM(expenses.base_amount), 0)").Row().Scan(&wholeSpent)
+	query.Session(&gorm.Session{}).
+		Joins("JOIN expense_items ON expense_items.expense_id = expenses.id").
+		Where("expense_items."+categoryFilter, categoryArg).
+		Select("COALESCE(SUM(expense_items.base_amount), 0)").Row().Scan(&itemSpent)
+	return models.Money(wholeSpent + itemSpent)
+}
+
+// assignBudgetScope links a budget to the tag or category it limits
+func assignBudgetScope(db *gorm.DB, userID uint, budget *models.Budget) error {
+	if budget.TagID != nil || budget.TagName != "" {
+		var tag models.Tag
+		if budget.TagID != nil {
+			if err := db.Where("id = ? AND user_id = ?", *budget.TagID, userID).First(&tag).Error; err != nil {
+				return errors.New("tag not found")
+			}
+		} else {
+			tags, err := resolveTags(db, userID, []string{budget.TagName})
+			if err != nil {
+				return err
+			}
+			if len(tags) == 0 {
+				return errors.New("tag name is required")
+			}
+			tag = tags[0]
+		}
+
+		budget.TagID = &tag.ID
+		budget.TagName = ""
+		budget.Tag = nil
+		budget.CategoryID = nil
+		budget.Category = ""
+		return nil
+	}
+
+	category, err := resolveCategory(db, userID, models.CategoryKindExpense, budget.CategoryID, budget.Category)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		return errors.New("category or tag is required")
+	}
+	budget.CategoryID = &category.ID
+	budget.Category = category.Name
+	return nil
+}
+
+// budgetStatus classifies how close spending is to the budget limit
+func budgetStatus(percentage float64) string {
+	if percentage >= 100 {
+		return "danger"
+	} else if percentage >= 75 {
+		return "warning"
+	}
+	return "safe"
+}
+
+// buildBudgetWithSpending attaches current spending figures to a budget
+func buildBudgetWithSpending(userID uint, budget models.Budget) models.BudgetWithSpending {
+	totalSpent := calculateBudgetSpent(userID, budget)
+	percentage := totalSpent.Percent(budget.Amount)
+
+	return models.BudgetWithSpending{
+		Budget:       budget,
+		CurrentSpent: totalSpent,
+		Remaining:    budget.Amount - totalSpent,
+		Percentage:   percentage,
+		Status:       budgetStatus(percentage),
+	}
+}
+
+// CreateBudget creates a new budget for a category or tag. Dates default as
+// for replaceBudget, and it is refused when an active budget already covers
+// the same scope for any of its dates.
 func CreateBudget(c *gin.Context) {
 	// Get user ID from JWT token
 	userID, err := getBudgetUserIDFromToken(c)
@@ -34,28 +144,26 @@ func CreateBudget(c *gin.Context) {
 		return
 	}
 
-	// Assign the user ID to the budget
-	budget.UserID = userID
-
-	// Set default dates for monthly budget if not provided
-	if budget.Period == "monthly" && budget.StartDate.IsZero() {
-		now := time.Now()
-		budget.StartDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		budget.EndDate = budget.StartDate.AddDate(0, 1, -1) // Last day of month
+	if err := prepareBudget(userID, &budget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Check if budget already exists for this category and period for this user
-	var existingBudget models.Budget
-	result := config.DB.Where("category = ? AND is_active = ? AND user_id = ? AND start_date <= ? AND end_date >= ?",
-		budget.Category, true, userID, time.Now(), time.Now()).First(&existingBudget)
+	// Ownership and IDs are set here, never by the client
+	budget.Model = gorm.Model{}
+	budget.UserID = userID
+	budget.IsActive = true
+	budget.Version = 1
 
-	if result.Error == nil {
+	if budgetOverlaps(userID, budget) {
 		c.JSON(http.StatusConflict, gin.H{"error": "Budget already exists for this category and period"})
 		return
 	}
 
-	budget.IsActive = true
-	config.DB.Create(&budget)
+	if err := config.DB.Create(&budget).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, budget)
 }
 
@@ -69,40 +177,13 @@ func GetBudgets(c *gin.Context) {
 	}
 
 	var budgets []models.Budget
-	config.DB.Where("is_active = ? AND user_id = ?", true, userID).Find(&budgets)
+	config.DB.Preload("Tag").Where("is_active = ? AND user_id = ?", true, userID).Find(&budgets)
 
 	var budgetsWithSpending []models.BudgetWithSpending
 
 	for _, budget := range budgets {
-		budgetWithSpending := models.BudgetWithSpending{
-			Budget: budget,
-		}
-
 		// Calculate current spending for this budget period and category for this user
-		var totalSpent float64
-		config.DB.Model(&models.Expense{}).
-			Where("category = ? AND user_id = ? AND created_at >= ? AND created_at <= ?",
-				budget.Category, userID, budget.StartDate, budget.EndDate).
-			Select("COALESCE(SUM(amount), 0)").
-			Row().Scan(&totalSpent)
-
-		budgetWithSpending.CurrentSpent = totalSpent
-		budgetWithSpending.Remaining = budget.Amount - totalSpent
-
-		if budget.Amount > 0 {
-			budgetWithSpending.Percentage = (totalSpent / budget.Amount) * 100
-		}
-
-		// Determine status
-		if budgetWithSpending.Percentage >= 100 {
-			budgetWithSpending.Status = "danger"
-		} else if budgetWithSpending.Percentage >= 75 {
-			budgetWithSpending.Status = "warning"
-		} else {
-			budgetWithSpending.Status = "safe"
-		}
-
-		budgetsWithSpending = append(budgetsWithSpending, budgetWithSpending)
+		budgetsWithSpending = append(budgetsWithSpending, buildBudgetWithSpending(userID, budget))
 	}
 
 	c.JSON(http.StatusOK, budgetsWithSpending)
@@ -120,7 +201,7 @@ func GetBudgetByID(c *gin.Context) {
 	id := c.Param("id")
 	var budget models.Budget
 
-	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&budget).Error; err != nil {
+	if err := config.DB.Preload("Tag").Where("id = ? AND user_id = ?", id, userID).First(&budget).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
 			return
@@ -129,38 +210,174 @@ func GetBudgetByID(c *gin.Context) {
 		return
 	}
 
-	budgetWithSpending := models.BudgetWithSpending{
-		Budget: budget,
+	// Calculate current spending for this user
+	budgetWithSpending := buildBudgetWithSpending(userID, budget)
+
+	c.Header("ETag", utils.VersionETag(budget.Version))
+	c.JSON(http.StatusOK, budgetWithSpending)
+}
+
+// budgetPeriods are the supported budget periods
+var budgetPeriods = map[string]bool{"monthly": true, "weekly": true, "custom": true}
+
+// budgetWritableFields are the JSON fields a client sets on a budget
+var budgetWritableFields = []string{
+	"amount", "category_id", "category", "tag_id", "tag_name", "period", "start_date", "end_date", "is_active",
+}
+
+// budgetPatchDependents drop the old scope from a patched budget when the
+// patch names a new category or tag
+var budgetPatchDependents = map[string][]string{
+	"category":    {"category_id", "tag_id"},
+	"category_id": {"category", "tag_id"},
+	"tag_name":    {"tag_id"},
+}
+
+// budgetOverlaps reports whether another active budget covers the same
+// category or tag for any part of the budget's dates
+func budgetOverlaps(userID uint, budget models.Budget) bool {
+	query := config.DB.Model(&models.Budget{}).
+		Where("is_active = ? AND user_id = ? AND id <> ? AND start_date <= ? AND end_date >= ?",
+			true, userID, budget.ID, budget.EndDate, budget.StartDate)
+	if budget.TagID != nil {
+		query = query.Where("tag_id = ?", *budget.TagID)
+	} else if budget.CategoryID != nil {
+		query = query.Where("category_id = ?", *budget.CategoryID)
+	} else {
+		query = query.Where("category = ?", budget.Category)
 	}
+	var count int64
+	query.Count(&count)
+	return count > 0
+}
 
-	// Calculate current spending for this user
-	var totalSpent float64
-	config.DB.Model(&models.Expense{}).
-		Where("category = ? AND user_id = ? AND created_at >= ? AND created_at <= ?",
-			budget.Category, userID, budget.StartDate, budget.EndDate).
-		Select("COALESCE(SUM(amount), 0)").
-		Row().Scan(&totalSpent)
+// prepareBudget validates a budget about to be created or replaced and fills
+// in its defaults. An omitted period means monthly, and a monthly or weekly
+// budget without dates covers the current month or week. Amounts are always
+// in the user's base currency.
+func prepareBudget(userID uint, budget *models.Budget) error {
+	if budget.Amount <= 0 {
+		return errors.New("Amount must be greater than zero")
+	}
 
-	budgetWithSpending.CurrentSpent = totalSpent
-	budgetWithSpending.Remaining = budget.Amount - totalSpent
+	if budget.Period == "" {
+		budget.Period = "monthly"
+	}
+	if !budgetPeriods[budget.Period] {
+		return errors.New("period must be monthly, weekly or custom")
+	}
+	now := time.Now()
+	switch {
+	case budget.Period == "monthly" && budget.StartDate.IsZero():
+		budget.StartDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	case budget.Period == "weekly" && budget.StartDate.IsZero():
+		daysSinceMonday := (int(now.Weekday()) + 6) % 7
+		budget.StartDate = time.Date(now.Year(), now.Month(), now.Day()-daysSinceMonday, 0, 0, 0, 0, now.Location())
+	case budget.StartDate.IsZero():
+		return errors.New("start_date is required for custom budgets")
+	}
+	if budget.EndDate.IsZero() {
+		switch budget.Period {
+		case "monthly":
+			budget.EndDate = budget.StartDate.AddDate(0, 1, -1)
+		case "weekly":
+			budget.EndDate = budget.StartDate.AddDate(0, 0, 6)
+		default:
+			return errors.New("end_date is required for custom budgets")
+		}
+	}
+	if budget.EndDate.Before(budget.StartDate) {
+		return errors.New("end_date must not be before start_date")
+	}
 
-	if budget.Amount > 0 {
-		budgetWithSpending.Percentage = (totalSpent / budget.Amount) * 100
+	if err := assignBudgetScope(config.DB, userID, budget); err != nil {
+		return err
 	}
+	budget.Currency = getUserBaseCurrency(userID)
+	return nil
+}
 
-	// Determine status
-	if budgetWithSpending.Percentage >= 100 {
-		budgetWithSpending.Status = "danger"
-	} else if budgetWithSpending.Percentage >= 75 {
-		budgetWithSpending.Status = "warning"
-	} else {
-		budgetWithSpending.Status = "safe"
+// replaceBudget validates input as the complete new state of a budget through
+// prepareBudget and saves it. Like replaceExpense it only saves over the
+// version that was loaded. It returns the HTTP status to respond with when
+// the input is rejected.
+func replaceBudget(userID uint, budget *models.Budget, input models.BudgetRequest) (int, error) {
+	updated := input.Budget
+	if err := prepareBudget(userID, &updated); err != nil {
+		return http.StatusBadRequest, err
 	}
 
-	c.JSON(http.StatusOK, budgetWithSpending)
+	// Ownership can't change
+	updated.Model = budget.Model
+	updated.UserID = userID
+	updated.IsActive = input.IsActive == nil || *input.IsActive
+	updated.Version = budget.Version + 1
+
+	if updated.IsActive && budgetOverlaps(userID, updated) {
+		return http.StatusConflict, errors.New("Budget already exists for this category and period")
+	}
+
+	// Select writes zero values too, so cleared fields really clear
+	result := config.DB.Model(budget).Omit(clause.Associations).Where("version = ?", budget.Version).
+		Select("category_id", "category", "tag_id", "amount", "currency", "period", "start_date", "end_date", "is_active", "version").
+		Updates(&updated)
+	if result.Error != nil {
+		return http.StatusInternalServerError, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return http.StatusPreconditionFailed, errVersionConflict
+	}
+	return http.StatusOK, nil
 }
 
-// UpdateBudget updates an existing budget
+// findBudgetForEdit loads the user's budget named in the URL and checks the
+// request's If-Match against its version
+func findBudgetForEdit(c *gin.Context, userID uint) (models.Budget, bool) {
+	var budget models.Budget
+	if err := config.DB.Preload("Tag").Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&budget).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+			return budget, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return budget, false
+	}
+	if !checkIfMatch(c, budget.Version, budget) {
+		return budget, false
+	}
+	return budget, true
+}
+
+// respondBudgetConflict reloads a budget that changed under an edit and
+// responds 412 with it
+func respondBudgetConflict(c *gin.Context, budgetID uint) {
+	var current models.Budget
+	if err := config.DB.Preload("Tag").First(&current, budgetID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
+		return
+	}
+	respondVersionConflict(c, current.Version, current)
+}
+
+// saveBudgetEdit applies an edit through replaceBudget and responds with the
+// saved budget
+func saveBudgetEdit(c *gin.Context, userID uint, budget models.Budget, input models.BudgetRequest) {
+	if status, err := replaceBudget(userID, &budget, input); err != nil {
+		if status == http.StatusPreconditionFailed {
+			respondBudgetConflict(c, budget.ID)
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	config.DB.Preload("Tag").First(&budget, budget.ID)
+	c.Header("ETag", utils.VersionETag(budget.Version))
+	c.JSON(http.StatusOK, budget)
+}
+
+// UpdateBudget replaces a budget with the request body (PUT). Fields left out
+// are cleared or take their defaults; use PATCH to change only some fields.
 func UpdateBudget(c *gin.Context) {
 	// Get user ID from JWT token
 	userID, err := getBudgetUserIDFromToken(c)
@@ -169,41 +386,50 @@ func UpdateBudget(c *gin.Context) {
 		return
 	}
 
-	id := c.Param("id")
-	var budget models.Budget
-
-	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&budget).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	budget, ok := findBudgetForEdit(c, userID)
+	if !ok {
 		return
 	}
 
-	var updateData models.Budget
-	if err := c.ShouldBindJSON(&updateData); err != nil {
+	var input models.BudgetRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Update budget fields
-	budget.Amount = updateData.Amount
-	budget.Category = updateData.Category
-	budget.Period = updateData.Period
+	saveBudgetEdit(c, userID, budget, input)
+}
+
+// PatchBudget changes some fields of a budget (PATCH) using a JSON Merge
+// Patch (RFC 7396): fields left out keep their value and null clears one
+func PatchBudget(c *gin.Context) {
+	// Get user ID from JWT token
+	userID, err := getBudgetUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	budget, ok := findBudgetForEdit(c, userID)
+	if !ok {
+		return
+	}
 
-	if !updateData.StartDate.IsZero() {
-		budget.StartDate = updateData.StartDate
+	body, ok := readMergePatch(c)
+	if !ok {
+		return
 	}
-	if !updateData.EndDate.IsZero() {
-		budget.EndDate = updateData.EndDate
+
+	var input models.BudgetRequest
+	if err := utils.ApplyMergePatch(budget, body, budgetWritableFields, budgetPatchDependents, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	config.DB.Save(&budget)
-	c.JSON(http.StatusOK, budget)
+	saveBudgetEdit(c, userID, budget, input)
 }
 
-// DeleteBudget soft deletes a budget (sets is_active to false)
+// DeleteBudget moves a budget to the trash, where it can be restored until purged
 func DeleteBudget(c *gin.Context) {
 	// Get user ID from JWT token
 	userID, err := getBudgetUserIDFromToken(c)
@@ -215,7 +441,7 @@ func DeleteBudget(c *gin.Context) {
 	id := c.Param("id")
 	var budget models.Budget
 
-	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&budget).Error; err != nil {
+	if err := config.DB.Preload("Tag").Where("id = ? AND user_id = ?", id, userID).First(&budget).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found"})
 			return
@@ -223,9 +449,20 @@ func DeleteBudget(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if !checkIfMatch(c, budget.Version, budget) {
+		return
+	}
 
-	budget.IsActive = false
-	config.DB.Save(&budget)
+	// Deleted budgets go to the trash and can be restored until purged
+	result := config.DB.Where("version = ?", budget.Version).Delete(&budget)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondBudgetConflict(c, budget.ID)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Budget deleted successfully"})
 }
 
@@ -243,34 +480,29 @@ func GetBudgetSummary(c *gin.Context) {
 
 	summary := gin.H{
 		"total_budgets":       len(budgets),
-		"total_budget_amount": 0.0,
-		"total_spent":         0.0,
+		"total_budget_amount": models.Money(0),
+		"total_spent":         models.Money(0),
 		"budgets_over_limit":  0,
 		"budgets_warning":     0,
 		"budgets_safe":        0,
 	}
 
-	var totalBudgetAmount, totalSpent float64
+	var totalBudgetAmount, totalSpent models.Money
 	var overLimit, warning, safe int
 
 	for _, budget := range budgets {
 		totalBudgetAmount += budget.Amount
 
-		var categorySpent float64
-		config.DB.Model(&models.Expense{}).
-			Where("category = ? AND user_id = ? AND created_at >= ? AND created_at <= ?",
-				budget.Category, userID, budget.StartDate, budget.EndDate).
-			Select("COALESCE(SUM(amount), 0)").
-			Row().Scan(&categorySpent)
+		categorySpent := calculateBudgetSpent(userID, budget)
 
 		totalSpent += categorySpent
 
-		percentage := (categorySpent / budget.Amount) * 100
-		if percentage >= 100 {
+		switch budgetStatus(categorySpent.Percent(budget.Amount)) {
+		case "danger":
 			overLimit++
-		} else if percentage >= 75 {
+		case "warning":
 			warning++
-		} else {
+		default:
 			safe++
 		}
 	}
@@ -280,7 +512,8 @@ func GetBudgetSummary(c *gin.Context) {
 	summary["budgets_over_limit"] = overLimit
 	summary["budgets_warning"] = warning
 	summary["budgets_safe"] = safe
-	summary["overall_percentage"] = (totalSpent / totalBudgetAmount) * 100
+	summary["overall_percentage"] = totalSpent.Percent(totalBudgetAmount)
+	summary["currency"] = getUserBaseCurrency(userID)
 
 	c.JSON(http.StatusOK, summary)
 }
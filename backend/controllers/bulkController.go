@@ -0,0 +1,385 @@
+package controllers
+
+import (
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBulkExpenses caps how many expenses one bulk edit may touch
+const maxBulkExpenses = 5000
+
+// bulkPreviewSize is how many changed expenses a dry run shows
+const bulkPreviewSize = 20
+
+// bulkTarget is a validated set of bulk changes with what they refer to looked up
+type bulkTarget struct {
+	category   *models.Category
+	accountID  *uint
+	merchantID *uint
+	addTags    []string
+	removeTags map[string]bool
+	taxSection *string
+}
+
+// bulkChange is one expense a bulk edit or its undo changes
+type bulkChange struct {
+	expense models.Expense
+	before  models.ExpenseSnapshot
+	after   models.ExpenseSnapshot
+}
+
+// resolveBulkChanges validates the changes of a bulk edit. Tags are only
+// named here; missing ones are created when the edit is saved, so dry runs
+// write nothing.
+func resolveBulkChanges(db *gorm.DB, userID uint, changes models.BulkExpenseChanges) (bulkTarget, error) {
+	target := bulkTarget{removeTags: make(map[string]bool)}
+
+	if changes.CategoryID != nil || changes.Category != "" {
+		category, err := resolveCategory(db, userID, models.CategoryKindExpense, changes.CategoryID, changes.Category)
+		if err != nil {
+			return target, err
+		}
+		if category == nil {
+			return target, errors.New("Invalid category")
+		}
+		target.category = category
+	}
+	if changes.AccountID != nil {
+		if _, err := findActiveAccount(db, userID, *changes.AccountID); err != nil {
+			return target, err
+		}
+		target.accountID = changes.AccountID
+	}
+	if changes.MerchantID != nil {
+		if err := db.Where("id = ? AND user_id = ?", *changes.MerchantID, userID).First(&models.Merchant{}).Error; err != nil {
+			return target, errors.New("merchant not found")
+		}
+		target.merchantID = changes.MerchantID
+	}
+	for _, name := range changes.AddTags {
+		if name = models.NormalizeTagName(name); name != "" {
+			target.addTags = append(target.addTags, name)
+		}
+	}
+	for _, name := range changes.RemoveTags {
+		if name = models.NormalizeTagName(name); name != "" {
+			target.removeTags[name] = true
+		}
+	}
+
+	if changes.TaxSection != nil {
+		section, err := utils.NormalizeTaxSection(*changes.TaxSection)
+		if err != nil {
+			return target, err
+		}
+		target.taxSection = &section
+	}
+
+	if target.category == nil && target.accountID == nil && target.merchantID == nil &&
+		len(target.addTags) == 0 && len(target.removeTags) == 0 && target.taxSection == nil {
+		return target, errors.New("No changes given")
+	}
+	return target, nil
+}
+
+// applyBulkChanges returns the state an expense would have after a bulk edit
+func applyBulkChanges(expense models.Expense, target bulkTarget) models.ExpenseSnapshot {
+	if target.category != nil {
+		expense.CategoryID = &target.category.ID
+		expense.Category = target.category.Name
+	}
+	if target.accountID != nil {
+		expense.AccountID = target.accountID
+	}
+	if target.merchantID != nil {
+		expense.MerchantID = target.merchantID
+	}
+	if target.taxSection != nil {
+		expense.TaxSection = *target.taxSection
+	}
+	after := utils.ExpenseSnapshotOf(expense)
+
+	tags := []string{}
+	seen := make(map[string]bool)
+	for _, name := range append(after.Tags, target.addTags...) {
+		if !seen[name] && !target.removeTags[name] {
+			seen[name] = true
+			tags = append(tags, name)
+		}
+	}
+	sort.Strings(tags)
+	after.Tags = tags
+	return after
+}
+
+// saveBulkChange writes the fields a bulk edit touches and records the
+// revision. It fails with errVersionConflict when the expense was edited
+// after it was loaded.
+func saveBulkChange(tx *gorm.DB, userID uint, change bulkChange, action string) error {
+	expense, after := change.expense, change.after
+	result := tx.Model(&models.Expense{}).Where("id = ? AND version = ?", expense.ID, expense.Version).
+		Updates(map[string]interface{}{
+			"category_id": after.CategoryID,
+			"category":    after.Category,
+			"account_id":  after.AccountID,
+			"merchant_id": after.MerchantID,
+			"tax_section": after.TaxSection,
+			"version":     gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict
+	}
+
+	tags, err := resolveTags(tx, userID, after.Tags)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(&expense).Association("Tags").Replace(tags); err != nil {
+		return err
+	}
+
+	_, err = recordExpenseRevision(tx, expense, change.before, after, action, nil)
+	return err
+}
+
+// BulkUpdateExpenses changes every expense matching the GET /expenses filters
+// in one go, e.g. moving old "Misc" expenses to "Groceries". With dry_run it
+// only counts the matching expenses and previews what would change.
+// Otherwise the edit runs in one transaction and is recorded so it can be
+// undone. A category change skips itemized expenses, whose spending is
+// categorized by their line items; they are listed as skipped_itemized.
+func BulkUpdateExpenses(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.BulkUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := filterExpenses(c, config.DB.Where("user_id = ?", userID), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	target, err := resolveBulkChanges(config.DB, userID, req.Changes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query = query.Session(&gorm.Session{})
+	var matched int64
+	if err := query.Model(&models.Expense{}).Count(&matched).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if matched > maxBulkExpenses {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   fmt.Sprintf("The filter matches %d expenses; narrow it to at most %d", matched, maxBulkExpenses),
+			"matched": matched,
+		})
+		return
+	}
+
+	var expenses []models.Expense
+	if err := preloadExpenseDetails(query).Order("spent_at, id").Find(&expenses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	changes := []bulkChange{}
+	preview := []models.BulkChangePreview{}
+	skippedItemized := []uint{}
+	for _, expense := range expenses {
+		if target.category != nil && len(expense.Items) > 0 {
+			skippedItemized = append(skippedItemized, expense.ID)
+			continue
+		}
+		before := utils.ExpenseSnapshotOf(expense)
+		after := applyBulkChanges(expense, target)
+		diff := utils.DiffExpenseSnapshots(before, after)
+		if len(diff) == 0 {
+			continue
+		}
+		changes = append(changes, bulkChange{expense: expense, before: before, after: after})
+		if len(preview) < bulkPreviewSize {
+			preview = append(preview, models.BulkChangePreview{
+				ExpenseID: expense.ID, Title: expense.Title, SpentAt: expense.SpentAt, Changes: diff,
+			})
+		}
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run":          true,
+			"matched":          len(expenses),
+			"changed":          len(changes),
+			"preview":          preview,
+			"skipped_itemized": skippedItemized,
+		})
+		return
+	}
+	if len(changes) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message":          "No expenses needed changing",
+			"matched":          len(expenses),
+			"changed":          0,
+			"skipped_itemized": skippedItemized,
+		})
+		return
+	}
+
+	operation := models.BulkOperation{
+		UserID:  userID,
+		Filter:  c.Request.URL.RawQuery,
+		Changes: req.Changes,
+		Matched: len(expenses),
+		Changed: len(changes),
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&operation).Error; err != nil {
+			return err
+		}
+		entries := make([]models.BulkOperationEntry, 0, len(changes))
+		for _, change := range changes {
+			if err := saveBulkChange(tx, userID, change, models.RevisionBulk); err != nil {
+				return err
+			}
+			entries = append(entries, models.BulkOperationEntry{
+				OperationID: operation.ID,
+				ExpenseID:   change.expense.ID,
+				Version:     change.expense.Version + 1,
+				Before:      change.before,
+			})
+		}
+		return tx.CreateInBatches(&entries, 500).Error
+	})
+	if errors.Is(err, errVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Some expenses changed while updating, try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Expenses updated successfully",
+		"operation":        operation,
+		"matched":          len(expenses),
+		"changed":          len(changes),
+		"skipped_itemized": skippedItemized,
+	})
+}
+
+// GetBulkOperations lists the user's recent bulk edits, newest first
+func GetBulkOperations(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var operations []models.BulkOperation
+	config.DB.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Limit(50).Find(&operations)
+	c.JSON(http.StatusOK, gin.H{"operations": operations})
+}
+
+// UndoBulkOperation puts the expenses a bulk edit changed back the way they
+// were. Expenses edited or deleted since are skipped and listed, so an undo
+// never overwrites a later change.
+func UndoBulkOperation(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var operation models.BulkOperation
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&operation).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bulk update not found"})
+		return
+	}
+	if operation.UndoneAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "This bulk update was already undone"})
+		return
+	}
+
+	var entries []models.BulkOperationEntry
+	config.DB.Where("operation_id = ?", operation.ID).Order("id").Find(&entries)
+
+	restored := 0
+	skipped := []uint{}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			var expense models.Expense
+			if err := preloadExpenseDetails(tx).Where("id = ? AND user_id = ?", entry.ExpenseID, userID).
+				First(&expense).Error; err != nil || expense.Version != entry.Version {
+				skipped = append(skipped, entry.ExpenseID)
+				continue
+			}
+
+			before := utils.ExpenseSnapshotOf(expense)
+			after := before
+			after.CategoryID, after.Category = entry.Before.CategoryID, entry.Before.Category
+			after.AccountID = entry.Before.AccountID
+			after.MerchantID = entry.Before.MerchantID
+			after.Tags = entry.Before.Tags
+			after.TaxSection = entry.Before.TaxSection
+			// A category deleted since can't be restored; merchants merged
+			// away since are simply unlinked
+			if after.CategoryID != nil {
+				if err := tx.Where("id = ? AND user_id = ?", *after.CategoryID, userID).First(&models.Category{}).Error; err != nil {
+					skipped = append(skipped, entry.ExpenseID)
+					continue
+				}
+			}
+			if after.MerchantID != nil {
+				if err := tx.Where("id = ? AND user_id = ?", *after.MerchantID, userID).First(&models.Merchant{}).Error; err != nil {
+					after.MerchantID = nil
+				}
+			}
+
+			if err := saveBulkChange(tx, userID, bulkChange{expense: expense, before: before, after: after}, models.RevisionBulkUndo); err != nil {
+				return err
+			}
+			restored++
+		}
+
+		now := time.Now()
+		operation.UndoneAt = &now
+		return tx.Model(&operation).Update("undone_at", now).Error
+	})
+	if errors.Is(err, errVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Some expenses changed while undoing, try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Bulk update undone",
+		"operation": operation,
+		"restored":  restored,
+		"skipped":   skipped,
+	})
+}
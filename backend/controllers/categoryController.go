@@ -0,0 +1,517 @@
+package controllers
+
+import (
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errUnknownCategory = errors.New("unknown category")
+
+// resolveCategory finds the user's category of the given kind by ID or by
+// case/whitespace-insensitive name. It returns nil when neither is given, and
+// errUnknownCategory when the name doesn't match anything so typos are rejected
+// instead of silently creating a new untracked category.
+func resolveCategory(db *gorm.DB, userID uint, kind string, categoryID *uint, name string) (*models.Category, error) {
+	if err := utils.EnsureDefaultCategories(db, userID); err != nil {
+		return nil, err
+	}
+	return findCategory(db, userID, kind, categoryID, name)
+}
+
+// findCategory is resolveCategory for callers that have already made sure the
+// user's default categories exist, e.g. once per statement import
+func findCategory(db *gorm.DB, userID uint, kind string, categoryID *uint, name string) (*models.Category, error) {
+	var category models.Category
+	if categoryID != nil && *categoryID != 0 {
+		if err := db.Where("id = ? AND user_id = ? AND kind = ?", *categoryID, userID, kind).First(&category).Error; err != nil {
+			return nil, fmt.Errorf("%w: id %d", errUnknownCategory, *categoryID)
+		}
+		return &category, nil
+	}
+
+	normalized := models.NormalizeCategoryName(name)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	if err := db.Where("user_id = ? AND kind = ? AND normalized_name = ?", userID, kind, normalized).First(&category).Error; err != nil {
+		return nil, fmt.Errorf("%w %q: create it first via POST /categories", errUnknownCategory, strings.TrimSpace(name))
+	}
+	return &category, nil
+}
+
+// assignExpenseCategory links an expense to its category record and keeps the
+// denormalized category name in sync
+func assignExpenseCategory(db *gorm.DB, userID uint, expense *models.Expense) error {
+	category, err := resolveCategory(db, userID, models.CategoryKindExpense, expense.CategoryID, expense.Category)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		expense.CategoryID = nil
+		expense.Category = ""
+		return nil
+	}
+	expense.CategoryID = &category.ID
+	expense.Category = category.Name
+	return nil
+}
+
+// buildCategoryTree nests a flat list of categories under their parents
+func buildCategoryTree(categories []models.Category) []models.Category {
+	children := make(map[uint][]models.Category)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var attach func(category models.Category, depth int) models.Category
+	attach = func(category models.Category, depth int) models.Category {
+		if depth > 10 {
+			return category
+		}
+		for _, child := range children[category.ID] {
+			category.Children = append(category.Children, attach(child, depth+1))
+		}
+		return category
+	}
+
+	roots := []models.Category{}
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, attach(category, 0))
+		}
+	}
+	return roots
+}
+
+// findUserCategory loads a category owned by the user, writing a 404 if missing
+func findUserCategory(c *gin.Context, userID uint, id interface{}) (*models.Category, bool) {
+	var category models.Category
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &category, true
+}
+
+// validateCategoryParent ensures a parent belongs to the user, has the same kind
+// and wouldn't create a cycle
+func validateCategoryParent(userID uint, kind string, categoryID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+
+	var parent models.Category
+	if err := config.DB.Where("id = ? AND user_id = ? AND kind = ?", *parentID, userID, kind).First(&parent).Error; err != nil {
+		return errors.New("parent category not found")
+	}
+
+	if categoryID == 0 {
+		return nil
+	}
+	descendants, err := utils.CategoryTreeIDs(config.DB, categoryID)
+	if err != nil {
+		return err
+	}
+	for _, id := range descendants {
+		if id == parent.ID {
+			return errors.New("a category cannot be nested under itself or its subcategories")
+		}
+	}
+	return nil
+}
+
+// GetCategories lists the user's categories as a tree (or flat with ?flat=true)
+func GetCategories(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := utils.EnsureDefaultCategories(config.DB, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var categories []models.Category
+	query.Order("name").Find(&categories)
+
+	if c.Query("flat") == "true" {
+		c.JSON(http.StatusOK, gin.H{"categories": categories})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"categories": buildCategoryTree(categories)})
+}
+
+// CreateCategory adds a user-defined category, optionally under a parent
+func CreateCategory(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.Join(strings.Fields(req.Name), " ")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category name is required"})
+		return
+	}
+
+	if err := utils.EnsureDefaultCategories(config.DB, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	kind := req.Kind
+	if kind == "" {
+		kind = models.CategoryKindExpense
+	}
+	if kind != models.CategoryKindExpense && kind != models.CategoryKindIncome {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kind must be expense or income"})
+		return
+	}
+
+	if err := validateCategoryParent(userID, kind, 0, req.ParentID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing models.Category
+	if config.DB.Where("user_id = ? AND normalized_name = ?", userID, models.NormalizeCategoryName(name)).
+		First(&existing).Error == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category already exists", "category": existing})
+		return
+	}
+
+	category := models.Category{
+		UserID:         userID,
+		Name:           name,
+		NormalizedName: models.NormalizeCategoryName(name),
+		Icon:           req.Icon,
+		Color:          req.Color,
+		ParentID:       req.ParentID,
+		Kind:           kind,
+	}
+	if err := config.DB.Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"category": category})
+}
+
+// UpdateCategory renames, recolors or re-parents a category. Renames are
+// propagated to every linked expense and budget.
+func UpdateCategory(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	category, ok := findUserCategory(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// parent_id: null moves the category to the top level, so leaving the
+	// field out has to be told apart from sending null
+	var req models.CategoryRequest
+	sent, err := bindJSONFields(c, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	parentSent := sent["parent_id"]
+
+	if parentSent {
+		if err := validateCategoryParent(userID, category.Kind, category.ID, req.ParentID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	name := strings.Join(strings.Fields(req.Name), " ")
+	if name != "" && models.NormalizeCategoryName(name) != category.NormalizedName {
+		var existing models.Category
+		if config.DB.Where("user_id = ? AND normalized_name = ? AND id <> ?", userID, models.NormalizeCategoryName(name), category.ID).
+			First(&existing).Error == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another category already has this name; merge them instead"})
+			return
+		}
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if name != "" {
+			category.Name = name
+			category.NormalizedName = models.NormalizeCategoryName(name)
+		}
+		if req.Icon != "" {
+			category.Icon = req.Icon
+		}
+		if req.Color != "" {
+			category.Color = req.Color
+		}
+		if parentSent {
+			category.ParentID = req.ParentID
+		}
+
+		if err := tx.Save(category).Error; err != nil {
+			return err
+		}
+
+		// Keep denormalized names on linked rows (including trashed ones) in sync
+		if err := tx.Unscoped().Model(&models.Expense{}).Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ExpenseItem{}).Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Income{}).Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RecurringExpense{}).Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RecurringIncome{}).Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.CategoryRule{}).Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Merchant{}).Where("default_category_id = ?", category.ID).
+			Update("default_category", category.Name).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Budget{}).Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"category": category})
+}
+
+// MergeCategory folds a category into another: linked expenses, budgets and
+// recurring rules (trashed ones included) are rewritten to the target,
+// subcategories move under it and the source is removed
+func MergeCategory(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	source, ok := findUserCategory(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req models.MergeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.TargetID == source.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge a category into itself"})
+		return
+	}
+
+	target, ok := findUserCategory(c, userID, req.TargetID)
+	if !ok {
+		return
+	}
+	if target.Kind != source.Kind {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge expense and income categories"})
+		return
+	}
+
+	var movedExpenses, movedBudgets, trashedBudgets int64
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Subcategories of the source move under the target
+		if target.ParentID != nil && *target.ParentID == source.ID {
+			if err := tx.Model(target).Update("parent_id", source.ParentID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.Category{}).Where("parent_id = ? AND id <> ?", source.ID, target.ID).
+			Update("parent_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Model(&models.Expense{}).Where("category_id = ?", source.ID).
+			Updates(map[string]interface{}{"category_id": target.ID, "category": target.Name, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return result.Error
+		}
+		movedExpenses = result.RowsAffected
+
+		if err := tx.Model(&models.ExpenseItem{}).Where("category_id = ?", source.ID).
+			Updates(map[string]interface{}{"category_id": target.ID, "category": target.Name}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Income{}).Where("category_id = ?", source.ID).
+			Updates(map[string]interface{}{"category_id": target.ID, "category": target.Name}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RecurringExpense{}).Where("category_id = ?", source.ID).
+			Updates(map[string]interface{}{"category_id": target.ID, "category": target.Name}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RecurringIncome{}).Where("category_id = ?", source.ID).
+			Updates(map[string]interface{}{"category_id": target.ID, "category": target.Name}).Error; err != nil {
+			return err
+		}
+
+		// A source budget that overlaps an existing target budget for the same
+		// period goes to the trash rather than producing two budgets for one category
+		result = tx.Model(&models.Budget{}).
+			Where("category_id = ? AND is_active = ? AND EXISTS (SELECT 1 FROM budgets t WHERE t.category_id = ? AND t.is_active = ? AND t.deleted_at IS NULL AND t.start_date = budgets.start_date)",
+				source.ID, true, target.ID, true).
+			UpdateColumns(map[string]interface{}{"deleted_at": time.Now(), "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return result.Error
+		}
+		trashedBudgets = result.RowsAffected
+
+		result = tx.Unscoped().Model(&models.Budget{}).Where("category_id = ?", source.ID).
+			Updates(map[string]interface{}{"category_id": target.ID, "category": target.Name, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return result.Error
+		}
+		movedBudgets = result.RowsAffected
+
+		if err := tx.Model(&models.CategoryRule{}).Where("category_id = ?", source.ID).
+			Updates(map[string]interface{}{"category_id": target.ID, "category": target.Name}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Merchant{}).Where("default_category_id = ?", source.ID).
+			Updates(map[string]interface{}{"default_category_id": target.ID, "default_category": target.Name}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(source).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Categories merged successfully",
+		"category":        target,
+		"expenses_moved":  movedExpenses,
+		"budgets_moved":   movedBudgets,
+		"budgets_trashed": trashedBudgets,
+	})
+}
+
+// DeleteCategory removes an unused category; its subcategories move up a level
+func DeleteCategory(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	category, ok := findUserCategory(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var expenseCount, itemCount, incomeCount, budgetCount int64
+	config.DB.Unscoped().Model(&models.Expense{}).Where("category_id = ?", category.ID).Count(&expenseCount) // Trashed expenses can still be restored
+	config.DB.Model(&models.ExpenseItem{}).Where("category_id = ?", category.ID).Count(&itemCount)
+	config.DB.Model(&models.Income{}).Where("category_id = ?", category.ID).Count(&incomeCount)
+	config.DB.Model(&models.Budget{}).Where("category_id = ?", category.ID).Count(&budgetCount) // Trashed budgets are refused on restore instead
+	if expenseCount > 0 || itemCount > 0 || incomeCount > 0 || budgetCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":         "Category is in use; merge it into another category instead",
+			"expenses":      expenseCount,
+			"expense_items": itemCount,
+			"incomes":       incomeCount,
+			"budgets":       budgetCount,
+		})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", category.ID).
+			Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Merchant{}).Where("default_category_id = ?", category.ID).
+			Updates(map[string]interface{}{"default_category_id": nil, "default_category": ""}).Error; err != nil {
+			return err
+		}
+		// Recurring rules carry on uncategorized rather than failing to generate
+		if err := tx.Model(&models.RecurringExpense{}).Where("category_id = ?", category.ID).
+			Updates(map[string]interface{}{"category_id": nil, "category": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RecurringIncome{}).Where("category_id = ?", category.ID).
+			Updates(map[string]interface{}{"category_id": nil, "category": ""}).Error; err != nil {
+			return err
+		}
+		// Rules pointing at the category stop setting one; those left with
+		// nothing to do are deleted, tag-only rules keep working
+		var ruleIDs []uint
+		if err := tx.Model(&models.CategoryRule{}).Where("category_id = ?", category.ID).
+			Pluck("id", &ruleIDs).Error; err != nil {
+			return err
+		}
+		if len(ruleIDs) > 0 {
+			if err := tx.Model(&models.CategoryRule{}).Where("id IN ?", ruleIDs).
+				Updates(map[string]interface{}{"category_id": nil, "category": ""}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ? AND (add_tags IS NULL OR add_tags IN ('', 'null', '[]'))", ruleIDs).
+				Delete(&models.CategoryRule{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(category).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
@@ -0,0 +1,477 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// claimTotals fills in each claim's total and expense count from its expenses
+func claimTotals(userID uint, claims []models.Claim) error {
+	if len(claims) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(claims))
+	for i, claim := range claims {
+		ids[i] = claim.ID
+	}
+
+	var rows []struct {
+		ClaimID uint
+		Total   int64
+		Count   int
+	}
+	if err := config.DB.Model(&models.Expense{}).
+		Select("claim_id, COALESCE(SUM(base_amount), 0) AS total, COUNT(*) AS count").
+		Where("claim_id IN ?", ids).Group("claim_id").Scan(&rows).Error; err != nil {
+		return err
+	}
+	totals := make(map[uint]int)
+	for i, row := range rows {
+		totals[row.ClaimID] = i
+	}
+
+	currency := getUserBaseCurrency(userID)
+	for i := range claims {
+		claims[i].Currency = currency
+		if row, ok := totals[claims[i].ID]; ok {
+			claims[i].Total = models.Money(rows[row].Total)
+			claims[i].ExpenseCount = rows[row].Count
+		}
+	}
+	return nil
+}
+
+// loadClaim loads one of the user's claims with its expenses and the income
+// that paid it back. It responds and returns false when it is missing.
+func loadClaim(c *gin.Context, userID uint, id interface{}) (models.Claim, bool) {
+	var claim models.Claim
+	err := config.DB.
+		Preload("Expenses", func(db *gorm.DB) *gorm.DB { return db.Order("spent_at, id") }).
+		Preload("Income").
+		Where("id = ? AND user_id = ?", id, userID).First(&claim).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Claim not found"})
+		return claim, false
+	}
+
+	claims := []models.Claim{claim}
+	if err := claimTotals(userID, claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return claim, false
+	}
+	return claims[0], true
+}
+
+// setClaimStatus moves a claim and all its expenses to a reimbursement status
+func setClaimStatus(tx *gorm.DB, claimID uint, status string, incomeID *uint, reimbursedAt *time.Time) error {
+	if err := tx.Model(&models.Claim{}).Where("id = ?", claimID).Updates(map[string]interface{}{
+		"status":        status,
+		"income_id":     incomeID,
+		"reimbursed_at": reimbursedAt,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Expense{}).Where("claim_id = ?", claimID).Updates(map[string]interface{}{
+		"reimbursement_status": status,
+		"version":              gorm.Expr("version + 1"),
+	}).Error
+}
+
+// openClaimMatches scores incomes against the user's claims still waiting to
+// be paid back, most likely first
+func openClaimMatches(userID uint, incomes []models.Income) ([]models.ClaimMatch, error) {
+	matches := []models.ClaimMatch{}
+	if len(incomes) == 0 {
+		return matches, nil
+	}
+
+	var claims []models.Claim
+	if err := config.DB.Where("user_id = ? AND status = ?", userID, models.ReimbursementClaimed).
+		Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	if err := claimTotals(userID, claims); err != nil {
+		return nil, err
+	}
+
+	for _, claim := range claims {
+		for _, income := range incomes {
+			confidence := utils.MatchClaimIncome(claim.Total, claim.SubmittedAt, claim.Reference, income)
+			if confidence == 0 {
+				continue
+			}
+			matches = append(matches, models.ClaimMatch{
+				ClaimID:    claim.ID,
+				ClaimTitle: claim.Title,
+				ClaimTotal: claim.Total,
+				Income:     income,
+				Difference: income.BaseAmount - claim.Total,
+				Confidence: confidence,
+			})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Confidence > matches[j].Confidence })
+	return matches, nil
+}
+
+// GetClaims lists the user's claims, newest first. Pass ?status=claimed or
+// ?status=reimbursed to list only one kind.
+func GetClaims(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if status := c.Query("status"); status != "" {
+		if status != models.ReimbursementClaimed && status != models.ReimbursementReimbursed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be claimed or reimbursed"})
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+
+	var claims []models.Claim
+	query.Order("submitted_at DESC, id DESC").Find(&claims)
+	if err := claimTotals(userID, claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"claims": claims})
+}
+
+// GetClaim returns a claim with its expenses
+func GetClaim(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	claim, ok := loadClaim(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, claim)
+}
+
+// CreateClaim submits pending reimbursable expenses together as one claim
+func CreateClaim(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.CreateClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.ExpenseIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A claim needs at least one expense"})
+		return
+	}
+
+	var expenses []models.Expense
+	config.DB.Where("id IN ? AND user_id = ?", req.ExpenseIDs, userID).Order("id").Find(&expenses)
+	seen := make(map[uint]bool)
+	for _, id := range req.ExpenseIDs {
+		seen[id] = true
+	}
+	if len(expenses) != len(seen) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Some expenses were not found"})
+		return
+	}
+	for _, expense := range expenses {
+		if !expense.Reimbursable {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Expense %d is not marked reimbursable", expense.ID)})
+			return
+		}
+		if expense.ClaimID != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Expense %d is already part of a claim", expense.ID)})
+			return
+		}
+	}
+
+	claim := models.Claim{
+		UserID:      userID,
+		Title:       req.Title,
+		Reference:   req.Reference,
+		Notes:       req.Notes,
+		Status:      models.ReimbursementClaimed,
+		SubmittedAt: req.SubmittedAt,
+	}
+	if claim.SubmittedAt.IsZero() {
+		claim.SubmittedAt = time.Now()
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&claim).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.Expense{}).
+			Where("id IN ? AND user_id = ? AND claim_id IS NULL AND reimbursable = ?", req.ExpenseIDs, userID, true).
+			Updates(map[string]interface{}{
+				"claim_id":             claim.ID,
+				"reimbursement_status": models.ReimbursementClaimed,
+				"version":              gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(expenses)) {
+			return errors.New("expenses changed while claiming, try again")
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, ok := loadClaim(c, userID, claim.ID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusCreated, claim)
+}
+
+// DeleteClaim withdraws a claim that hasn't been paid back; its expenses go
+// back to pending so they can be claimed again
+func DeleteClaim(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var claim models.Claim
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&claim).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Claim not found"})
+		return
+	}
+	if claim.Status == models.ReimbursementReimbursed {
+		c.JSON(http.StatusConflict, gin.H{"error": "The claim has been paid back; undo the reimbursement first"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Expense{}).Where("claim_id = ?", claim.ID).Updates(map[string]interface{}{
+			"claim_id":             nil,
+			"reimbursement_status": models.ReimbursementPending,
+			"version":              gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&claim).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Claim deleted successfully"})
+}
+
+// ReimburseClaim records the income that paid back a claim. Its expenses
+// become reimbursed and stop counting against budgets.
+func ReimburseClaim(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.ReimburseClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, ok := loadClaim(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+	if claim.Status == models.ReimbursementReimbursed {
+		c.JSON(http.StatusConflict, gin.H{"error": "The claim has already been paid back"})
+		return
+	}
+
+	var income models.Income
+	if err := config.DB.Where("id = ? AND user_id = ?", req.IncomeID, userID).First(&income).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Income not found"})
+		return
+	}
+	var linked int64
+	config.DB.Model(&models.Claim{}).Where("income_id = ?", income.ID).Count(&linked)
+	if linked > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This income already pays back another claim"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		return setClaimStatus(tx, claim.ID, models.ReimbursementReimbursed, &income.ID, &income.ReceivedAt)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, ok = loadClaim(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Claim marked as reimbursed",
+		"claim":      claim,
+		"difference": income.BaseAmount - claim.Total,
+	})
+}
+
+// UndoClaimReimbursement unlinks the payment from a claim, which goes back
+// to waiting for payment
+func UndoClaimReimbursement(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	claim, ok := loadClaim(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+	if claim.Status != models.ReimbursementReimbursed {
+		c.JSON(http.StatusConflict, gin.H{"error": "The claim hasn't been paid back"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		return setClaimStatus(tx, claim.ID, models.ReimbursementClaimed, nil, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, ok = loadClaim(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reimbursement removed", "claim": claim})
+}
+
+// GetClaimMatches suggests which recorded incomes are the payments for the
+// user's open claims: amounts within 2% of a claim's total, received after
+// it was submitted and not yet linked to a claim
+func GetClaimMatches(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var earliest *time.Time
+	config.DB.Model(&models.Claim{}).Where("user_id = ? AND status = ?", userID, models.ReimbursementClaimed).
+		Select("MIN(submitted_at)").Row().Scan(&earliest)
+	if earliest == nil {
+		c.JSON(http.StatusOK, gin.H{"matches": []models.ClaimMatch{}})
+		return
+	}
+
+	var incomes []models.Income
+	config.DB.Where("user_id = ? AND received_at >= ?", userID, earliest.AddDate(0, 0, -1)).
+		Where("id NOT IN (SELECT income_id FROM claims WHERE income_id IS NOT NULL AND deleted_at IS NULL)").
+		Order("received_at, id").Find(&incomes)
+
+	matches, err := openClaimMatches(userID, incomes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"matches": matches})
+}
+
+// GetClaimReport returns a claim as a PDF claim form, or as CSV with
+// ?format=csv, listing each expense with its receipts
+func GetClaimReport(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format", "supported": []string{"pdf", "csv"}})
+		return
+	}
+
+	claim, ok := loadClaim(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := models.ClaimReport{
+		Claim:         claim,
+		UserName:      user.Name,
+		GeneratedAt:   time.Now(),
+		ReceiptCounts: make(map[uint]int),
+	}
+	var counts []struct {
+		ExpenseID uint
+		Count     int
+	}
+	config.DB.Model(&models.Receipt{}).Select("expense_id, COUNT(*) AS count").
+		Where("expense_id IN (SELECT id FROM expenses WHERE claim_id = ?)", claim.ID).
+		Group("expense_id").Scan(&counts)
+	for _, count := range counts {
+		report.ReceiptCounts[count.ExpenseID] = count.Count
+	}
+
+	var buf bytes.Buffer
+	contentType := "application/pdf"
+	if format == "csv" {
+		contentType = exportContentTypes["csv"]
+		writer := csv.NewWriter(&buf)
+		writer.Write(utils.ClaimCSVHeader)
+		for _, expense := range claim.Expenses {
+			writer.Write(utils.ClaimCSVRecord(expense, report.ReceiptCounts[expense.ID]))
+		}
+		writer.Write([]string{"", "", "Total", "", "", "", "", claim.Total.String(), claim.Currency, ""})
+		writer.Flush()
+		err = writer.Error()
+	} else {
+		err = utils.WriteClaimReportPDF(&buf, report)
+	}
+	if err != nil {
+		log.Printf("❌ Failed to render claim report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render report"})
+		return
+	}
+
+	filename := fmt.Sprintf("capify-claim-%d.%s", claim.ID, format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
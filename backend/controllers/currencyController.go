@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// getUserBaseCurrency returns the currency the user's budgets and reports are kept in
+func getUserBaseCurrency(userID uint) string {
+	var user models.User
+	if err := config.DB.Select("id, base_currency").First(&user, userID).Error; err != nil || user.BaseCurrency == "" {
+		return models.DefaultCurrency
+	}
+	return user.BaseCurrency
+}
+
+// applyCurrencyConversion normalizes an expense's currency (defaulting to the
+// user's base currency) and fills in the converted base amount
+func applyCurrencyConversion(db *gorm.DB, userID uint, expense *models.Expense) error {
+	if expense.Currency == "" {
+		expense.Currency = getUserBaseCurrency(userID)
+	}
+
+	currency, err := utils.NormalizeCurrency(expense.Currency)
+	if err != nil {
+		return err
+	}
+	expense.Currency = currency
+
+	return utils.ConvertExpenseToBase(db, expense, getUserBaseCurrency(userID))
+}
+
+// currencyErrorStatus maps conversion failures to an HTTP status
+func currencyErrorStatus(err error) int {
+	if errors.Is(err, utils.ErrNoExchangeRate) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
+
+// GetExchangeRates lists stored exchange rates, optionally filtered by pair and date
+func GetExchangeRates(c *gin.Context) {
+	query := config.DB.Model(&models.ExchangeRate{})
+
+	if base := c.Query("base"); base != "" {
+		query = query.Where("base_currency = ?", strings.ToUpper(base))
+	}
+	if quote := c.Query("quote"); quote != "" {
+		query = query.Where("quote_currency = ?", strings.ToUpper(quote))
+	}
+	if on := c.Query("date"); on != "" {
+		date, err := time.Parse("2006-01-02", on)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date, expected YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("effective_date <= ?", date)
+	}
+
+	var rates []models.ExchangeRate
+	query.Order("effective_date DESC, base_currency, quote_currency").Limit(500).Find(&rates)
+	c.JSON(http.StatusOK, gin.H{"exchange_rates": rates})
+}
+
+// ImportExchangeRates loads rates from a JSON body or an uploaded CSV/JSON file (admin only)
+func ImportExchangeRates(c *gin.Context) {
+	var inputs []utils.ExchangeRateInput
+
+	if file, err := c.FormFile("file"); err == nil {
+		reader, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read uploaded file"})
+			return
+		}
+		defer reader.Close()
+
+		if strings.EqualFold(filepath.Ext(file.Filename), ".json") {
+			inputs, err = utils.ParseExchangeRatesJSON(reader)
+		} else {
+			inputs, err = utils.ParseExchangeRatesCSV(reader)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		var req struct {
+			Rates []utils.ExchangeRateInput `json:"rates" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		inputs = req.Rates
+	}
+
+	saved, updated, err := utils.SaveExchangeRates(config.DB, inputs, "admin")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "Exchange rates imported successfully",
+		"rates_saved":          saved,
+		"expenses_reconverted": updated,
+	})
+}
+
+// UpdateBaseCurrency changes the user's reporting currency, re-converting every
+// expense and income at its own date and every budget at today's rate.
+// Trashed rows and the item shares of itemized expenses are converted too.
+// Only rows whose converted amount changes get a new version, and setting the
+// current base currency again changes nothing.
+func UpdateBaseCurrency(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.UpdateBaseCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	baseCurrency, err := utils.NormalizeCurrency(req.BaseCurrency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if baseCurrency == getUserBaseCurrency(userID) {
+		c.JSON(http.StatusOK, gin.H{
+			"message":       "Base currency is already " + baseCurrency,
+			"base_currency": baseCurrency,
+		})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Trashed rows are converted too, so they come back in the new base
+		// currency when restored
+		var expenses []models.Expense
+		if err := tx.Unscoped().Where("user_id = ?", userID).Find(&expenses).Error; err != nil {
+			return err
+		}
+		for _, expense := range expenses {
+			previous := expense
+			if err := utils.ConvertExpenseToBase(tx, &expense, baseCurrency); err != nil {
+				return err
+			}
+			if expense.BaseAmount == previous.BaseAmount && expense.BaseCurrency == previous.BaseCurrency &&
+				expense.ExchangeRate == previous.ExchangeRate {
+				continue
+			}
+			updates := map[string]interface{}{
+				"base_amount":   expense.BaseAmount,
+				"base_currency": expense.BaseCurrency,
+				"exchange_rate": expense.ExchangeRate,
+			}
+			if expense.BaseAmount != previous.BaseAmount || expense.BaseCurrency != previous.BaseCurrency {
+				updates["version"] = gorm.Expr("version + 1")
+			}
+			if err := tx.Unscoped().Model(&models.Expense{}).Where("id = ?", expense.ID).UpdateColumns(updates).Error; err != nil {
+				return err
+			}
+			if err := utils.ReallocateItemBaseAmounts(tx, expense.ID, expense.BaseAmount); err != nil {
+				return err
+			}
+		}
+
+		var incomes []models.Income
+		if err := tx.Unscoped().Where("user_id = ?", userID).Find(&incomes).Error; err != nil {
+			return err
+		}
+		for _, income := range incomes {
+			previous := income
+			if err := utils.ConvertIncomeToBase(tx, &income, baseCurrency); err != nil {
+				return err
+			}
+			if income.BaseAmount == previous.BaseAmount && income.BaseCurrency == previous.BaseCurrency &&
+				income.ExchangeRate == previous.ExchangeRate {
+				continue
+			}
+			if err := tx.Unscoped().Model(&models.Income{}).Where("id = ?", income.ID).UpdateColumns(map[string]interface{}{
+				"base_amount":   income.BaseAmount,
+				"base_currency": income.BaseCurrency,
+				"exchange_rate": income.ExchangeRate,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		var budgets []models.Budget
+		if err := tx.Unscoped().Where("user_id = ?", userID).Find(&budgets).Error; err != nil {
+			return err
+		}
+		for _, budget := range budgets {
+			// Budgets already in the new currency keep their amounts
+			if budget.Currency == baseCurrency {
+				continue
+			}
+			rate, err := utils.FindExchangeRate(tx, budget.Currency, baseCurrency, time.Now())
+			if err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&models.Budget{}).Where("id = ?", budget.ID).UpdateColumns(map[string]interface{}{
+				"amount":   utils.ConvertMoney(budget.Amount, rate),
+				"currency": baseCurrency,
+				"version":  gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.User{}).Where("id = ?", userID).Update("base_currency", baseCurrency).Error
+	})
+	if err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Base currency updated successfully",
+		"base_currency": baseCurrency,
+	})
+}
@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxDuplicateWindowDays caps ?window_days so scans stay meaningful
+const maxDuplicateWindowDays = 7
+
+// duplicateWindow reads ?window_days, defaulting to utils.DefaultDuplicateWindowDays
+func duplicateWindow(c *gin.Context) (int, error) {
+	value := c.Query("window_days")
+	if value == "" {
+		return utils.DefaultDuplicateWindowDays, nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 || days > maxDuplicateWindowDays {
+		return 0, fmt.Errorf("window_days must be between 0 and %d", maxDuplicateWindowDays)
+	}
+	return days, nil
+}
+
+// Values of ?on_duplicate on expense creation
+const (
+	duplicateWarn   = "warn"   // Create and list likely duplicates (default)
+	duplicateReject = "reject" // Refuse with 409 and the likely duplicates
+	duplicateAllow  = "allow"  // Create without checking
+)
+
+// findCreateDuplicates checks a new expense against the user's history per
+// ?on_duplicate. It responds and returns false when the request should stop.
+func findCreateDuplicates(c *gin.Context, expense models.Expense) ([]models.DuplicateMatch, bool) {
+	policy := c.DefaultQuery("on_duplicate", duplicateWarn)
+	switch policy {
+	case duplicateAllow:
+		return []models.DuplicateMatch{}, true
+	case duplicateWarn, duplicateReject:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "on_duplicate must be warn, reject or allow"})
+		return nil, false
+	}
+
+	duplicates, err := utils.FindDuplicateCandidates(config.DB, expense, utils.DefaultDuplicateWindowDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if policy == duplicateReject && len(duplicates) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      "This looks like an expense you already logged",
+			"duplicates": duplicates,
+		})
+		return nil, false
+	}
+	return duplicates, true
+}
+
+// keepPreference ranks which of a set of duplicates to keep: entries typed
+// in by the user beat imported ones, then categorized ones, then the oldest
+func keepPreference(a, b models.Expense) bool {
+	if (a.ImportBatchID == nil) != (b.ImportBatchID == nil) {
+		return a.ImportBatchID == nil
+	}
+	if (a.CategoryID != nil) != (b.CategoryID != nil) {
+		return a.CategoryID != nil
+	}
+	return a.ID < b.ID
+}
+
+// GetDuplicateExpenses scans the user's history for expenses that look like
+// the same purchase logged more than once. Takes the GET /expenses filters
+// and ?window_days (default 1).
+func GetDuplicateExpenses(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	window, err := duplicateWindow(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := filterExpenses(c, config.DB.Where("user_id = ?", userID), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expenses []models.Expense
+	query.Order("spent_at, id").Find(&expenses)
+
+	// Only expenses for the same money can match, so compare within buckets.
+	// Expenses are in date order, so each scan stops once past the window.
+	buckets := make(map[string][]int)
+	for i, expense := range expenses {
+		key := fmt.Sprintf("%s|%d", expense.BaseCurrency, expense.BaseAmount)
+		buckets[key] = append(buckets[key], i)
+	}
+
+	// Matching pairs are joined into groups, so three copies of one purchase
+	// come back as one group
+	parent := make([]int, len(expenses))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	type match struct {
+		index int
+		score float64
+	}
+	var matches []match
+	for _, indexes := range buckets {
+		for a := 0; a < len(indexes); a++ {
+			for b := a + 1; b < len(indexes); b++ {
+				first, second := expenses[indexes[a]], expenses[indexes[b]]
+				if second.SpentAt.Sub(first.SpentAt) > time.Duration(window+1)*24*time.Hour {
+					break
+				}
+				score, _ := utils.MatchDuplicate(first, second, window)
+				if score == 0 {
+					continue
+				}
+				parent[find(indexes[b])] = find(indexes[a])
+				matches = append(matches, match{index: indexes[a], score: score})
+			}
+		}
+	}
+
+	members := make(map[int][]models.Expense)
+	for i, expense := range expenses {
+		members[find(i)] = append(members[find(i)], expense)
+	}
+	scores := make(map[int]float64)
+	for _, m := range matches {
+		if root := find(m.index); m.score > scores[root] {
+			scores[root] = m.score
+		}
+	}
+
+	groups := []models.DuplicateGroup{}
+	for root, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		keep := group[0]
+		for _, expense := range group[1:] {
+			if keepPreference(expense, keep) {
+				keep = expense
+			}
+		}
+		groups = append(groups, models.DuplicateGroup{KeepID: keep.ID, Expenses: group, Confidence: scores[root]})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Confidence != groups[j].Confidence {
+			return groups[i].Confidence > groups[j].Confidence
+		}
+		return groups[i].Expenses[0].SpentAt.After(groups[j].Expenses[0].SpentAt)
+	})
+
+	c.JSON(http.StatusOK, gin.H{"groups": groups, "count": len(groups), "window_days": window})
+}
+
+// MergeExpenses folds duplicates into the expense in the URL. Details the kept
+// expense is missing (category, account, merchant, notes, location, the
+// reimbursable flag) are taken from the duplicates, tags and receipts are
+// combined, and the duplicates go to the trash, where they can still be
+// restored.
+func MergeExpenses(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.MergeExpensesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expense models.Expense
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&expense).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found or unauthorized"})
+		return
+	}
+	before, err := loadExpenseSnapshot(config.DB, &expense)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkIfMatch(c, expense.Version, expense) {
+		return
+	}
+
+	var duplicates []models.Expense
+	config.DB.Preload("Tags").Where("id IN ? AND id <> ? AND user_id = ?", req.DuplicateIDs, expense.ID, userID).
+		Order("id").Find(&duplicates)
+	if len(duplicates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No duplicates to merge"})
+		return
+	}
+	for _, duplicate := range duplicates {
+		if duplicate.ClaimID != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Expense %d is part of a claim; delete the claim first", duplicate.ID)})
+			return
+		}
+	}
+
+	updates := map[string]interface{}{"version": gorm.Expr("version + 1")}
+	tags := append([]models.Tag{}, expense.Tags...)
+	seenTags := make(map[uint]bool)
+	for _, tag := range expense.Tags {
+		seenTags[tag.ID] = true
+	}
+	duplicateIDs := make([]uint, len(duplicates))
+	for i, duplicate := range duplicates {
+		duplicateIDs[i] = duplicate.ID
+		for _, tag := range duplicate.Tags {
+			if !seenTags[tag.ID] {
+				seenTags[tag.ID] = true
+				tags = append(tags, tag)
+			}
+		}
+
+		if expense.CategoryID == nil && duplicate.CategoryID != nil && updates["category_id"] == nil {
+			updates["category_id"], updates["category"] = duplicate.CategoryID, duplicate.Category
+		}
+		if expense.AccountID == nil && duplicate.AccountID != nil && updates["account_id"] == nil {
+			updates["account_id"] = duplicate.AccountID
+		}
+		if expense.MerchantID == nil && duplicate.MerchantID != nil && updates["merchant_id"] == nil {
+			updates["merchant_id"] = duplicate.MerchantID
+		}
+		if expense.Description == "" && duplicate.Description != "" && updates["description"] == nil {
+			updates["description"] = duplicate.Description
+		}
+		if !expense.Reimbursable && duplicate.Reimbursable && updates["reimbursable"] == nil {
+			updates["reimbursable"], updates["reimbursement_status"] = true, models.ReimbursementPending
+		}
+		if expense.Latitude == nil && duplicate.Latitude != nil && updates["latitude"] == nil {
+			updates["latitude"], updates["longitude"], updates["place_name"] =
+				duplicate.Latitude, duplicate.Longitude, duplicate.PlaceName
+		}
+	}
+
+	var revision *models.ExpenseRevision
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&expense).Omit(clause.Associations).Where("version = ?", expense.Version).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		if err := tx.Model(&expense).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Receipt{}).Where("expense_id IN ?", duplicateIDs).
+			Update("expense_id", expense.ID).Error; err != nil {
+			return err
+		}
+		result = tx.Where("id IN ? AND user_id = ?", duplicateIDs, userID).Delete(&models.Expense{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(duplicateIDs)) {
+			return errors.New("duplicates changed while merging, try again")
+		}
+
+		after, err := loadExpenseSnapshot(tx, &expense)
+		if err != nil {
+			return err
+		}
+		revision, err = recordExpenseRevision(tx, expense, before, after, models.RevisionUpdate, nil)
+		return err
+	})
+	if errors.Is(err, errVersionConflict) {
+		respondExpenseConflict(c, expense.ID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", utils.VersionETag(expense.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Expenses merged successfully",
+		"expense":  expense,
+		"merged":   duplicateIDs,
+		"revision": revision,
+	})
+}
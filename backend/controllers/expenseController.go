@@ -1,11 +1,21 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"finance-app-backend/config"
 	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Get user ID from JWT token
@@ -17,6 +27,90 @@ func getUserIDFromToken(c *gin.Context) (uint, error) {
 	return userID.(uint), nil
 }
 
+// bindJSONFields binds the request body like ShouldBindJSON and also reports
+// which top-level fields it contained, for updates where sending null clears
+// a field but leaving it out keeps it
+func bindJSONFields(c *gin.Context, dest interface{}) (map[string]bool, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, err
+	}
+	if err := binding.JSON.BindBody(body, dest); err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	sent := make(map[string]bool, len(fields))
+	for name := range fields {
+		sent[name] = true
+	}
+	return sent, nil
+}
+
+// filterExpenses applies the query-string filters shared by listing, export
+// and bulk edits: from/to spending dates (YYYY-MM-DD, inclusive), category_id
+// (including subcategories and line items), account_id, merchant_id,
+// reimbursement_status, tax_section and the tag filters
+func filterExpenses(c *gin.Context, query *gorm.DB, userID uint) (*gorm.DB, error) {
+	if from := c.Query("from"); from != "" {
+		fromDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, errors.New("Invalid from date, expected YYYY-MM-DD")
+		}
+		query = query.Where("spent_at >= ?", fromDate)
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, errors.New("Invalid to date, expected YYYY-MM-DD")
+		}
+		query = query.Where("spent_at < ?", toDate.AddDate(0, 0, 1))
+	}
+	if value := c.Query("category_id"); value != "" {
+		categoryID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("Invalid category_id")
+		}
+		ids, err := utils.CategoryTreeIDs(config.DB, uint(categoryID))
+		if err != nil {
+			return nil, err
+		}
+		// Itemized expenses match when any of their lines is in the category
+		query = query.Where("category_id IN ? OR id IN (SELECT expense_id FROM expense_items WHERE category_id IN ?)", ids, ids)
+	}
+	if value := c.Query("account_id"); value != "" {
+		accountID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("Invalid account_id")
+		}
+		query = query.Where("account_id = ?", accountID)
+	}
+	if value := c.Query("merchant_id"); value != "" {
+		merchantID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("Invalid merchant_id")
+		}
+		query = query.Where("merchant_id = ?", merchantID)
+	}
+	if status := c.Query("reimbursement_status"); status != "" {
+		if status != models.ReimbursementPending && status != models.ReimbursementClaimed && status != models.ReimbursementReimbursed {
+			return nil, errors.New("reimbursement_status must be pending, claimed or reimbursed")
+		}
+		query = query.Where("reimbursement_status = ?", status)
+	}
+	if value := c.Query("tax_section"); value != "" {
+		section, err := utils.NormalizeTaxSection(value)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("tax_section = ?", section)
+	}
+
+	return applyTagFilters(c, query, userID), nil
+}
+
 func GetExpenses(c *gin.Context) {
 	// Get user ID from JWT token
 	userID, err := getUserIDFromToken(c)
@@ -26,11 +120,37 @@ func GetExpenses(c *gin.Context) {
 	}
 
 	var expenses []models.Expense
-	// Filter expenses by user ID
-	config.DB.Where("user_id = ?", userID).Find(&expenses)
+	// Filter expenses by user ID, newest spending first
+	query, err := filterExpenses(c, config.DB.Where("user_id = ?", userID), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preloadExpenseDetails(query).Order("spent_at DESC, id DESC").Find(&expenses)
 	c.JSON(http.StatusOK, gin.H{"expenses": expenses})
 }
 
+// GetExpenseByID returns one expense with its tags and items. The ETag header
+// carries its version for use as If-Match on later edits.
+func GetExpenseByID(c *gin.Context) {
+	// Get user ID from JWT token
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var expense models.Expense
+	if err := preloadExpenseDetails(config.DB).Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&expense).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found or unauthorized"})
+		return
+	}
+
+	c.Header("ETag", utils.VersionETag(expense.Version))
+	c.JSON(http.StatusOK, expense)
+}
+
 func CreateExpense(c *gin.Context) {
 	// Get user ID from JWT token
 	userID, err := getUserIDFromToken(c)
@@ -45,11 +165,99 @@ func CreateExpense(c *gin.Context) {
 		return
 	}
 
+	if expense.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+		return
+	}
+
 	// Assign the user ID to the expense
 	expense.UserID = userID
+	expense.RecurringExpenseID = nil
+	expense.ImportBatchID = nil
+	expense.Version = 1
+
+	section, err := utils.NormalizeTaxSection(expense.TaxSection)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expense.TaxSection = section
+
+	// Expenses can be flagged for reimbursement on create but only claims claim them
+	expense.ClaimID = nil
+	expense.ReimbursementStatus = ""
+	if expense.Reimbursable {
+		expense.ReimbursementStatus = models.ReimbursementPending
+	}
 
-	config.DB.Create(&expense)
-	c.JSON(http.StatusCreated, gin.H{"expense": expense})
+	// Default the transaction date to now when the client doesn't backdate it
+	if expense.SpentAt.IsZero() {
+		expense.SpentAt = time.Now()
+	}
+
+	if err := utils.ValidateCoordinates(expense.Latitude, expense.Longitude); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expense.PlaceName = strings.TrimSpace(expense.PlaceName)
+
+	// Link to a known category so spelling variants can't escape budgets
+	if err := assignExpenseCategory(config.DB, userID, &expense); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Line items must add up to the total and each may carry its own category
+	if err := prepareExpenseItems(config.DB, userID, expense.Amount, expense.Items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The paying account must be the user's; its currency is the default
+	if err := assignExpenseAccount(config.DB, userID, &expense); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Convert into the user's base currency at the spend date
+	if err := applyCurrencyConversion(config.DB, userID, &expense); err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	utils.AllocateItemBaseAmounts(expense.Items, expense.BaseAmount)
+
+	// Link the payee; its default category applies when none was given
+	if err := assignExpenseMerchant(config.DB, userID, &expense); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Fill in a missing category and extra tags from the user's rules
+	appliedRules, err := applyCategoryRules(config.DB, userID, &expense)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags, err := resolveTags(config.DB, userID, expense.TagNames)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	expense.Tags = tags
+	expense.TagNames = nil
+
+	// Look for the same purchase already logged by hand, SMS or an import
+	duplicates, ok := findCreateDuplicates(c, expense)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Create(&expense).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"expense": expense, "applied_rules": appliedRules, "duplicates": duplicates})
 }
 
 func DeleteExpense(c *gin.Context) {
@@ -64,17 +272,226 @@ func DeleteExpense(c *gin.Context) {
 
 	// First, check if expense exists and belongs to user
 	var expense models.Expense
-	result := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&expense)
+	result := preloadExpenseDetails(config.DB).Where("id = ? AND user_id = ?", id, userID).First(&expense)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found or unauthorized"})
 		return
 	}
+	if !checkIfMatch(c, expense.Version, expense) {
+		return
+	}
+	if expense.ClaimID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "The expense is part of a claim; delete the claim first"})
+		return
+	}
 
-	// Delete the expense
-	config.DB.Delete(&expense)
+	// Deleted expenses go to the trash and can be restored until purged.
+	// Deleting only the version checked keeps a concurrent edit from being lost.
+	result = config.DB.Where("version = ?", expense.Version).Delete(&expense)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondExpenseConflict(c, expense.ID)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Expense deleted successfully"})
 }
 
+// expenseWritableFields are the JSON fields a client sets on an expense;
+// everything else is derived or fixed at creation
+var expenseWritableFields = []string{
+	"title", "amount", "currency", "category_id", "category", "description", "spent_at",
+	"account_id", "merchant_id", "latitude", "longitude", "place_name", "tag_names", "items", "reimbursable",
+	"tax_section",
+}
+
+// expensePatchDependents drop derived fields from the patched document when
+// what they derive from changes, e.g. a new title re-matches the merchant
+var expensePatchDependents = map[string][]string{
+	"title":       {"merchant_id"},
+	"vpa":         {"merchant_id"},
+	"category":    {"category_id"},
+	"category_id": {"category"},
+}
+
+// replaceExpense validates input as the complete new state of an expense and
+// saves it, recording the change from before as a revision in the same
+// transaction. Omitted fields are cleared or take the defaults they would get
+// on create, except spent_at, which is required. Input that matches the
+// current state is not saved at all. The save only applies to the version of
+// expense that was loaded; if another edit got in first it fails with
+// errVersionConflict. It returns the revision, which is nil when nothing
+// changed, and the HTTP status to respond with when the input is rejected.
+func replaceExpense(userID uint, expense *models.Expense, before models.ExpenseSnapshot, input models.Expense, action string, revertedTo *int) (*models.ExpenseRevision, int, error) {
+	if input.Amount <= 0 {
+		return nil, http.StatusBadRequest, errors.New("Amount must be greater than zero")
+	}
+	if input.SpentAt.IsZero() {
+		return nil, http.StatusBadRequest, errors.New("spent_at is required")
+	}
+	if err := utils.ValidateCoordinates(input.Latitude, input.Longitude); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	// Ownership and source links can't be changed by the client
+	input.ID = expense.ID
+	input.UserID = userID
+	input.RecurringExpenseID = expense.RecurringExpenseID
+	input.ImportBatchID = expense.ImportBatchID
+	input.Version = expense.Version + 1
+	input.PlaceName = strings.TrimSpace(input.PlaceName)
+
+	section, err := utils.NormalizeTaxSection(input.TaxSection)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	input.TaxSection = section
+
+	// Claims move the reimbursement status along; edits only flag or unflag
+	input.ClaimID = expense.ClaimID
+	input.ReimbursementStatus = expense.ReimbursementStatus
+	switch {
+	case input.Reimbursable && input.ReimbursementStatus == "":
+		input.ReimbursementStatus = models.ReimbursementPending
+	case !input.Reimbursable && expense.ClaimID != nil:
+		return nil, http.StatusConflict, errors.New("The expense is part of a claim; delete the claim first")
+	case !input.Reimbursable:
+		input.ReimbursementStatus = ""
+	}
+
+	if err := assignExpenseCategory(config.DB, userID, &input); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := prepareExpenseItems(config.DB, userID, input.Amount, input.Items); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := assignExpenseAccount(config.DB, userID, &input); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	// Without an explicit merchant, match one from the title as on create
+	merchant, err := expenseMerchant(config.DB, userID, input.MerchantID, input.Title, input.VPA)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	input.MerchantID = nil
+	if merchant != nil {
+		input.MerchantID = &merchant.ID
+	}
+
+	// Re-derive the converted amount from the values the expense will end up with
+	if err := applyCurrencyConversion(config.DB, userID, &input); err != nil {
+		return nil, currencyErrorStatus(err), err
+	}
+	utils.AllocateItemBaseAmounts(input.Items, input.BaseAmount)
+
+	// An edit that changes nothing isn't saved, so the version and the
+	// client's ETag stay as they are
+	proposed := input
+	proposed.Tags = []models.Tag{}
+	seenTags := make(map[string]bool)
+	for _, name := range input.TagNames {
+		if name = models.NormalizeTagName(name); name != "" && !seenTags[name] {
+			seenTags[name] = true
+			proposed.Tags = append(proposed.Tags, models.Tag{Name: name})
+		}
+	}
+	if len(utils.DiffExpenseSnapshots(before, utils.ExpenseSnapshotOf(proposed))) == 0 {
+		return nil, http.StatusOK, nil
+	}
+
+	original := *expense
+	var revision *models.ExpenseRevision
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Select writes zero values too, so cleared fields really clear
+		result := tx.Model(expense).Omit(clause.Associations).Where("version = ?", expense.Version).
+			Select("title", "amount", "currency", "category_id", "category", "description", "spent_at",
+				"account_id", "merchant_id", "latitude", "longitude", "place_name",
+				"base_amount", "base_currency", "exchange_rate", "reimbursable", "reimbursement_status", "tax_section", "version").
+			Updates(&input)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+
+		// Learn from the user's recategorizations for rule suggestions; a
+		// revert only goes back to an earlier choice
+		if action == models.RevisionUpdate && input.CategoryID != nil {
+			if err := recordCategoryCorrection(tx, original, input.Title, *input.CategoryID); err != nil {
+				return err
+			}
+		}
+		if err := replaceExpenseItems(tx, expense.ID, input.Items); err != nil {
+			return err
+		}
+
+		tags, err := resolveTags(tx, userID, input.TagNames)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(expense).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+
+		after, err := loadExpenseSnapshot(tx, expense)
+		if err != nil {
+			return err
+		}
+		revision, err = recordExpenseRevision(tx, *expense, before, after, action, revertedTo)
+		return err
+	})
+	if errors.Is(err, errVersionConflict) {
+		return nil, http.StatusPreconditionFailed, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return revision, http.StatusOK, nil
+}
+
+// saveExpenseEdit applies an edit through replaceExpense, which records it as
+// a revision, responding with the saved expense
+func saveExpenseEdit(c *gin.Context, userID uint, expense models.Expense, before models.ExpenseSnapshot, input models.Expense) {
+	revision, status, err := replaceExpense(userID, &expense, before, input, models.RevisionUpdate, nil)
+	if err != nil {
+		if status == http.StatusPreconditionFailed {
+			respondExpenseConflict(c, expense.ID)
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", utils.VersionETag(expense.Version))
+	c.JSON(http.StatusOK, gin.H{"expense": expense, "revision": revision})
+}
+
+// findExpenseForEdit loads the user's expense named in the URL with its tags
+// and items, and its current state for the revision history. It also checks
+// the request's If-Match against the expense's version.
+func findExpenseForEdit(c *gin.Context, userID uint) (models.Expense, models.ExpenseSnapshot, bool) {
+	var expense models.Expense
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&expense).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found or unauthorized"})
+		return expense, models.ExpenseSnapshot{}, false
+	}
+	before, err := loadExpenseSnapshot(config.DB, &expense)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return expense, before, false
+	}
+	if !checkIfMatch(c, expense.Version, expense) {
+		return expense, before, false
+	}
+	return expense, before, true
+}
+
+// UpdateExpense replaces an expense with the request body (PUT). Fields left
+// out are cleared; use PATCH to change only some fields.
 func UpdateExpense(c *gin.Context) {
 	// Get user ID from JWT token
 	userID, err := getUserIDFromToken(c)
@@ -83,27 +500,148 @@ func UpdateExpense(c *gin.Context) {
 		return
 	}
 
-	id := c.Param("id")
+	expense, before, ok := findExpenseForEdit(c, userID)
+	if !ok {
+		return
+	}
 
-	// First, check if expense exists and belongs to user
-	var expense models.Expense
-	result := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&expense)
-	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found or unauthorized"})
+	var input models.Expense
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	saveExpenseEdit(c, userID, expense, before, input)
+}
+
+// PatchExpense changes some fields of an expense (PATCH) using a JSON Merge
+// Patch (RFC 7396): fields left out keep their value and null clears one.
+// Arrays such as tag_names and items are replaced as a whole.
+func PatchExpense(c *gin.Context) {
+	// Get user ID from JWT token
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	expense, before, ok := findExpenseForEdit(c, userID)
+	if !ok {
+		return
+	}
+
+	body, ok := readMergePatch(c)
+	if !ok {
+		return
+	}
+
+	current := expense
+	current.TagNames = before.Tags
+	var input models.Expense
+	if err := utils.ApplyMergePatch(current, body, expenseWritableFields, expensePatchDependents, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Bind the updated data
-	var updatedExpense models.Expense
-	if err := c.ShouldBindJSON(&updatedExpense); err != nil {
+	saveExpenseEdit(c, userID, expense, before, input)
+}
+
+// readMergePatch reads a JSON Merge Patch request body. Both
+// application/merge-patch+json and plain application/json are accepted.
+func readMergePatch(c *gin.Context) ([]byte, bool) {
+	switch c.ContentType() {
+	case "application/merge-patch+json", "application/json", "":
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Send a JSON merge patch (application/merge-patch+json)"})
+		return nil, false
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return body, true
+}
+
+// errVersionConflict is returned when an edit lost the race to another edit
+var errVersionConflict = errors.New("This was changed since you loaded it; reload and try again")
+
+// checkIfMatch compares the request's If-Match header with the version about
+// to be changed. When it is stale it responds 412 with the current
+// representation, so the client can reapply its change. Requests without
+// If-Match go through.
+func checkIfMatch(c *gin.Context, version uint, current interface{}) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || utils.ETagMatches(header, utils.VersionETag(version)) {
+		return true
+	}
+	respondVersionConflict(c, version, current)
+	return false
+}
+
+// respondVersionConflict responds 412 with the current representation and its ETag
+func respondVersionConflict(c *gin.Context, version uint, current interface{}) {
+	c.Header("ETag", utils.VersionETag(version))
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": errVersionConflict.Error(), "current": current})
+}
+
+// respondExpenseConflict reloads an expense that changed under an edit and
+// responds 412 with it
+func respondExpenseConflict(c *gin.Context, expenseID uint) {
+	var current models.Expense
+	if err := preloadExpenseDetails(config.DB).First(&current, expenseID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found or unauthorized"})
 		return
 	}
+	respondVersionConflict(c, current.Version, current)
+}
+
+// prepareExpenseItems validates line items against the expense total and
+// links each to the user's category. Base amounts are filled in later by
+// utils.AllocateItemBaseAmounts once the expense has been converted.
+func prepareExpenseItems(db *gorm.DB, userID uint, total models.Money, items []models.ExpenseItem) error {
+	if err := utils.ValidateExpenseItems(total, items); err != nil {
+		return err
+	}
+
+	for i := range items {
+		category, err := resolveCategory(db, userID, models.CategoryKindExpense, items[i].CategoryID, items[i].Category)
+		if err != nil {
+			return fmt.Errorf("item %d: %w", i+1, err)
+		}
+		items[i].ID = 0
+		items[i].ExpenseID = 0
+		items[i].UserID = userID
+		items[i].Position = i
+		items[i].CategoryID = nil
+		items[i].Category = ""
+		if category != nil {
+			items[i].CategoryID = &category.ID
+			items[i].Category = category.Name
+		}
+	}
+	return nil
+}
 
-	// Ensure user ID remains the same (security)
-	updatedExpense.UserID = userID
+// replaceExpenseItems swaps an expense's line items for a new set; an empty
+// set turns it back into a single-category expense
+func replaceExpenseItems(tx *gorm.DB, expenseID uint, items []models.ExpenseItem) error {
+	if err := tx.Where("expense_id = ?", expenseID).Delete(&models.ExpenseItem{}).Error; err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	for i := range items {
+		items[i].ExpenseID = expenseID
+	}
+	return tx.Create(&items).Error
+}
 
-	// Update the expense
-	config.DB.Model(&expense).Updates(updatedExpense)
-	c.JSON(http.StatusOK, gin.H{"expense": expense})
+// preloadExpenseDetails loads the tags and ordered line items of expenses
+func preloadExpenseDetails(query *gorm.DB) *gorm.DB {
+	return query.Preload("Tags").Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	})
 }
@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize is how many expenses are loaded per query while streaming
+const exportBatchSize = 500
+
+// exportContentTypes maps each supported export format to its MIME type
+var exportContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"json": "application/json; charset=utf-8",
+	"ofx":  "application/x-ofx",
+}
+
+// startExport validates the format and writes download headers
+func startExport(c *gin.Context, name string, formats ...string) (string, bool) {
+	format := c.DefaultQuery("format", "csv")
+	supported := false
+	for _, f := range formats {
+		if f == format {
+			supported = true
+		}
+	}
+	if !supported {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format", "supported": formats})
+		return "", false
+	}
+
+	filename := fmt.Sprintf("capify-%s-%s.%s", name, time.Now().Format("20060102"), format)
+	c.Header("Content-Type", exportContentTypes[format])
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	return format, true
+}
+
+// exportRange reports the statement period for OFX, falling back to the data itself
+func exportRange(c *gin.Context, query *gorm.DB) (time.Time, time.Time) {
+	from, _ := time.Parse("2006-01-02", c.Query("from"))
+	to, _ := time.Parse("2006-01-02", c.Query("to"))
+	if from.IsZero() {
+		query.Session(&gorm.Session{}).Select("MIN(spent_at)").Row().Scan(&from)
+	}
+	if to.IsZero() {
+		to = time.Now()
+	} else {
+		to = to.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return from, to
+}
+
+// ExportExpenses streams the user's expenses as CSV, JSON or OFX. It takes the
+// same filters as GET /expenses (from, to, category_id, account_id, tags).
+func ExportExpenses(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	query, err := filterExpenses(c, config.DB.Model(&models.Expense{}).Where("user_id = ?", userID), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// OFX carries a single currency, so it exports base amounts in the
+	// user's current base currency
+	baseCurrency := getUserBaseCurrency(userID)
+	var from, to time.Time
+	if c.Query("format") == "ofx" {
+		query = query.Where("base_currency = ?", baseCurrency)
+		from, to = exportRange(c, query)
+	}
+
+	format, ok := startExport(c, "expenses", "csv", "json", "ofx")
+	if !ok {
+		return
+	}
+
+	var (
+		writeBatch func([]models.Expense) error
+		finish     func() error
+	)
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(c.Writer)
+		if err := writer.Write(utils.ExpenseCSVHeader); err != nil {
+			return
+		}
+		writeBatch = func(expenses []models.Expense) error {
+			for _, expense := range expenses {
+				if err := writer.Write(utils.ExpenseCSVRecord(expense)); err != nil {
+					return err
+				}
+			}
+			writer.Flush()
+			return writer.Error()
+		}
+		finish = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case "json":
+		fmt.Fprintf(c.Writer, `{"schema_version":%d,"exported_at":%q,"expenses":[`,
+			utils.ExportSchemaVersion, time.Now().UTC().Format(time.RFC3339))
+		first := true
+		writeBatch = func(expenses []models.Expense) error {
+			for _, expense := range expenses {
+				data, err := json.Marshal(expense)
+				if err != nil {
+					return err
+				}
+				if !first {
+					c.Writer.WriteString(",")
+				}
+				first = false
+				if _, err := c.Writer.Write(data); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		finish = func() error {
+			_, err := c.Writer.WriteString("]}\n")
+			return err
+		}
+	case "ofx":
+		writer, err := utils.NewOFXWriter(c.Writer, baseCurrency, c.DefaultQuery("account_id", "ALL"), from, to)
+		if err != nil {
+			return
+		}
+		writeBatch = func(expenses []models.Expense) error {
+			for _, expense := range expenses {
+				if err := writer.WriteExpense(expense); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		finish = writer.Close
+	}
+
+	err = findExpensePages(query, func(expenses []models.Expense) error {
+		if err := writeBatch(expenses); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent, so the client sees a truncated file
+		log.Printf("❌ Expense export failed for user %d: %v", userID, err)
+		return
+	}
+	if err := finish(); err != nil {
+		log.Printf("❌ Expense export failed for user %d: %v", userID, err)
+	}
+}
+
+// findExpensePages loads the expenses matching query in spending order,
+// exportBatchSize at a time. Pages continue after the last (spent_at, id)
+// seen; FindInBatches pages by id alone and would skip or repeat rows whenever
+// spending order and id order differ, as they do after backdating or imports.
+func findExpensePages(query *gorm.DB, fn func(expenses []models.Expense) error) error {
+	query = query.Session(&gorm.Session{})
+	var last *models.Expense
+	for {
+		page := preloadExpenseDetails(query)
+		if last != nil {
+			page = page.Where("(spent_at, id) > (?, ?)", last.SpentAt, last.ID)
+		}
+		var expenses []models.Expense
+		if err := page.Order("spent_at, id").Limit(exportBatchSize).Find(&expenses).Error; err != nil {
+			return err
+		}
+		if len(expenses) == 0 {
+			return nil
+		}
+		if err := fn(expenses); err != nil {
+			return err
+		}
+		if len(expenses) < exportBatchSize {
+			return nil
+		}
+		last = &expenses[len(expenses)-1]
+	}
+}
+
+// ExportBudgets writes the user's budgets with their current spending as CSV or JSON
+func ExportBudgets(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	query := config.DB.Preload("Tag").Where("user_id = ?", userID)
+	if c.Query("include_inactive") != "true" {
+		query = query.Where("is_active = ?", true)
+	}
+	var budgets []models.Budget
+	if err := query.Order("id").Find(&budgets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	format, ok := startExport(c, "budgets", "csv", "json")
+	if !ok {
+		return
+	}
+
+	results := make([]models.BudgetWithSpending, len(budgets))
+	for i, budget := range budgets {
+		results[i] = buildBudgetWithSpending(userID, budget)
+	}
+
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(c.Writer)
+		writer.Write(utils.BudgetCSVHeader)
+		for _, budget := range results {
+			writer.Write(utils.BudgetCSVRecord(budget))
+		}
+		writer.Flush()
+	case "json":
+		json.NewEncoder(c.Writer).Encode(gin.H{
+			"schema_version": utils.ExportSchemaVersion,
+			"exported_at":    time.Now().UTC().Format(time.RFC3339),
+			"budgets":        results,
+		})
+	}
+}
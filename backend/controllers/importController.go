@@ -0,0 +1,639 @@
+package controllers
+
+import (
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxStatementBytes = 5 << 20
+	maxStatementRows  = 20000
+)
+
+// statementUpload is a parsed statement file together with its import settings
+type statementUpload struct {
+	FileName string
+	Mapping  models.ImportMapping
+	Account  *models.Account
+	Parsed   utils.StatementParse
+}
+
+// mappingFromForm overlays mapping fields sent with the upload onto a saved
+// mapping (or an empty one)
+func mappingFromForm(c *gin.Context, mapping *models.ImportMapping) error {
+	fields := map[string]*string{
+		"date_column":      &mapping.DateColumn,
+		"narration_column": &mapping.NarrationColumn,
+		"debit_column":     &mapping.DebitColumn,
+		"credit_column":    &mapping.CreditColumn,
+		"amount_column":    &mapping.AmountColumn,
+		"date_format":      &mapping.DateFormat,
+	}
+	for name, target := range fields {
+		if value, ok := c.GetPostForm(name); ok {
+			*target = strings.TrimSpace(value)
+		}
+	}
+	if value := c.PostForm("header_row"); value != "" {
+		row, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("header_row must be a number")
+		}
+		mapping.HeaderRow = row
+	}
+	return nil
+}
+
+// loadStatementUpload reads and parses a multipart statement upload, writing
+// an error response and returning false on failure
+func loadStatementUpload(c *gin.Context, userID uint) (*statementUpload, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxStatementBytes+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload the statement CSV in the 'file' field"})
+		return nil, false
+	}
+	if fileHeader.Size > maxStatementBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Statement must be at most %d bytes", maxStatementBytes)})
+		return nil, false
+	}
+
+	upload := &statementUpload{FileName: fileHeader.Filename}
+
+	if mappingID := c.PostForm("mapping_id"); mappingID != "" {
+		if err := config.DB.Where("id = ? AND user_id = ?", mappingID, userID).First(&upload.Mapping).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Import mapping not found"})
+			return nil, false
+		}
+	}
+	if err := mappingFromForm(c, &upload.Mapping); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if accountID := c.PostForm("account_id"); accountID != "" {
+		id, err := strconv.ParseUint(accountID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account_id"})
+			return nil, false
+		}
+		account, err := findActiveAccount(config.DB, userID, uint(id))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		upload.Account = account
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxStatementBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	records, err := utils.ReadStatementCSV(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid CSV: " + err.Error()})
+		return nil, false
+	}
+	if len(records) > maxStatementRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Statement has too many rows", "max": maxStatementRows})
+		return nil, false
+	}
+
+	upload.Parsed, err = utils.ParseStatement(records, upload.Mapping)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	markImportDuplicates(config.DB, userID, upload)
+	return upload, true
+}
+
+// markImportDuplicates flags rows matching an existing expense or income on the
+// same day for the same amount. Debit rows also match an expense for the same
+// amount dated a day or so apart when the payee looks the same, as when a
+// purchase logged by hand posts to the bank the next day. Each existing
+// transaction absorbs at most one row, so two identical coffees on one day
+// only dedupe against two records.
+func markImportDuplicates(db *gorm.DB, userID uint, upload *statementUpload) {
+	var from, to time.Time
+	for _, row := range upload.Parsed.Rows {
+		if row.Error != "" {
+			continue
+		}
+		if from.IsZero() || row.Date.Before(from) {
+			from = row.Date
+		}
+		if row.Date.After(to) {
+			to = row.Date
+		}
+	}
+	if from.IsZero() {
+		return
+	}
+	window := utils.DefaultDuplicateWindowDays
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location()).AddDate(0, 0, 1)
+
+	day := func(t time.Time) string {
+		return t.In(time.Local).Format("2006-01-02")
+	}
+
+	currency := getUserBaseCurrency(userID)
+	var accountID *uint
+	var expenses []models.Expense
+	expenseQuery := db.Where("user_id = ? AND spent_at >= ? AND spent_at < ?",
+		userID, from.AddDate(0, 0, -window), to.AddDate(0, 0, window))
+	incomeQuery := db.Model(&models.Income{}).Select("amount, received_at").
+		Where("user_id = ? AND received_at >= ? AND received_at < ?", userID, from, to)
+	if upload.Account != nil {
+		currency = upload.Account.Currency
+		accountID = &upload.Account.ID
+		expenseQuery = expenseQuery.Where("account_id = ?", upload.Account.ID)
+		incomeQuery = incomeQuery.Where("account_id = ?", upload.Account.ID)
+	}
+	expenseQuery.Order("spent_at, id").Find(&expenses)
+
+	var incomes []models.Income
+	incomeQuery.Find(&incomes)
+	incomeCounts := make(map[string]int)
+	for _, income := range incomes {
+		incomeCounts[fmt.Sprintf("%s|%d", day(income.ReceivedAt), income.Amount)]++
+	}
+
+	used := make(map[uint]bool)
+	markExpense := func(row *models.ImportRow, expense models.Expense) {
+		row.Duplicate = true
+		row.DuplicateOf = &expense.ID
+		used[expense.ID] = true
+	}
+
+	// Exact matches first so they aren't taken by a looser match of another row
+	for i := range upload.Parsed.Rows {
+		row := &upload.Parsed.Rows[i]
+		if row.Error != "" {
+			continue
+		}
+		if row.Direction == models.DirectionCredit {
+			key := fmt.Sprintf("%s|%d", day(row.Date), row.Amount)
+			if incomeCounts[key] > 0 {
+				row.Duplicate = true
+				incomeCounts[key]--
+			}
+			continue
+		}
+		for _, expense := range expenses {
+			if !used[expense.ID] && expense.Amount == row.Amount && day(expense.SpentAt) == day(row.Date) {
+				markExpense(row, expense)
+				break
+			}
+		}
+	}
+
+	for i := range upload.Parsed.Rows {
+		row := &upload.Parsed.Rows[i]
+		if row.Error != "" || row.Duplicate || row.Direction == models.DirectionCredit {
+			continue
+		}
+		candidate := models.Expense{
+			Title:     importTitle(row.Narration),
+			Amount:    row.Amount,
+			Currency:  currency,
+			SpentAt:   row.Date,
+			AccountID: accountID,
+		}
+		var best *models.Expense
+		var bestScore float64
+		for j, expense := range expenses {
+			if used[expense.ID] {
+				continue
+			}
+			if score, _ := utils.MatchDuplicate(candidate, expense, window); score > bestScore {
+				best, bestScore = &expenses[j], score
+			}
+		}
+		if best != nil {
+			markExpense(row, *best)
+		}
+	}
+}
+
+// importTitle shortens a bank narration into an expense title
+func importTitle(narration string) string {
+	title := strings.TrimSpace(utils.TruncateRunes(strings.Join(strings.Fields(narration), " "), 80))
+	if title == "" {
+		title = "Imported transaction"
+	}
+	return title
+}
+
+// columnName describes a resolved column for the preview
+func columnName(headers []string, index int) string {
+	if index < 0 {
+		return ""
+	}
+	if index < len(headers) && strings.TrimSpace(headers[index]) != "" {
+		return strings.TrimSpace(headers[index])
+	}
+	return strconv.Itoa(index + 1)
+}
+
+// PreviewImport parses a statement and shows what would be imported, without saving
+func PreviewImport(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	upload, ok := loadStatementUpload(c, userID)
+	if !ok {
+		return
+	}
+
+	limit := 100
+	if value := c.PostForm("limit"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	var valid, duplicates, invalid int
+	var debits, credits models.Money
+	for _, row := range upload.Parsed.Rows {
+		switch {
+		case row.Error != "":
+			invalid++
+		case row.Duplicate:
+			duplicates++
+		default:
+			valid++
+			if row.Direction == models.DirectionDebit {
+				debits += row.Amount
+			} else {
+				credits += row.Amount
+			}
+		}
+	}
+
+	rows := upload.Parsed.Rows
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	cols := upload.Parsed.Columns
+	headers := upload.Parsed.Headers
+	c.JSON(http.StatusOK, gin.H{
+		"detected": gin.H{
+			"header_row":    cols.Header + 1,
+			"date_format":   upload.Parsed.DateFormat,
+			"number_format": upload.Parsed.NumberFormat,
+			"columns": gin.H{
+				"date":      columnName(headers, cols.Date),
+				"narration": columnName(headers, cols.Narration),
+				"debit":     columnName(headers, cols.Debit),
+				"credit":    columnName(headers, cols.Credit),
+				"amount":    columnName(headers, cols.Amount),
+			},
+		},
+		"summary": gin.H{
+			"rows":       len(upload.Parsed.Rows),
+			"importable": valid,
+			"duplicates": duplicates,
+			"errors":     invalid,
+			"debits":     debits,
+			"credits":    credits,
+		},
+		"rows": rows,
+	})
+}
+
+// CommitImport imports a statement in one transaction. Duplicates are skipped
+// unless include_duplicates=true. The returned batch can be rolled back.
+func CommitImport(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	upload, ok := loadStatementUpload(c, userID)
+	if !ok {
+		return
+	}
+	includeDuplicates := c.PostForm("include_duplicates") == "true"
+
+	currency := getUserBaseCurrency(userID)
+	var accountID *uint
+	if upload.Account != nil {
+		currency = upload.Account.Currency
+		accountID = &upload.Account.ID
+	}
+
+	batch := models.ImportBatch{
+		UserID:    userID,
+		AccountID: accountID,
+		FileName:  upload.FileName,
+		Status:    models.ImportStatusCommitted,
+		RowCount:  len(upload.Parsed.Rows),
+	}
+	if upload.Mapping.ID != 0 {
+		batch.MappingID = &upload.Mapping.ID
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Remember the layout for next time when asked to
+		if name := strings.TrimSpace(c.PostForm("save_mapping_as")); name != "" {
+			mapping := upload.Mapping
+			mapping.ID = 0
+			mapping.UserID = userID
+			mapping.Name = name
+			if err := tx.Create(&mapping).Error; err != nil {
+				return err
+			}
+			batch.MappingID = &mapping.ID
+		}
+
+		if err := tx.Create(&batch).Error; err != nil {
+			return err
+		}
+
+		// Look the user's categories and rules up once, not once per row
+		if err := utils.EnsureDefaultCategories(tx, userID); err != nil {
+			return err
+		}
+		rules, err := utils.LoadRules(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range upload.Parsed.Rows {
+			switch {
+			case row.Error != "":
+				batch.ErrorCount++
+				continue
+			case row.Duplicate:
+				batch.DuplicateCount++
+				if !includeDuplicates {
+					continue
+				}
+			}
+
+			if row.Direction == models.DirectionCredit {
+				income := models.Income{
+					UserID:        userID,
+					Title:         importTitle(row.Narration),
+					Amount:        row.Amount,
+					Currency:      currency,
+					Description:   row.Narration,
+					ReceivedAt:    row.Date,
+					AccountID:     accountID,
+					ImportBatchID: &batch.ID,
+				}
+				if err := prepareIncome(tx, userID, &income); err != nil {
+					return fmt.Errorf("row %d: %w", row.Row, err)
+				}
+				if err := tx.Create(&income).Error; err != nil {
+					return err
+				}
+				batch.IncomeCount++
+				continue
+			}
+
+			expense := models.Expense{
+				UserID:        userID,
+				Title:         importTitle(row.Narration),
+				Amount:        row.Amount,
+				Currency:      currency,
+				Description:   row.Narration,
+				SpentAt:       row.Date,
+				AccountID:     accountID,
+				ImportBatchID: &batch.ID,
+			}
+			if err := applyCurrencyConversion(tx, userID, &expense); err != nil {
+				return fmt.Errorf("row %d: %w", row.Row, err)
+			}
+			if err := assignExpenseMerchant(tx, userID, &expense); err != nil {
+				return fmt.Errorf("row %d: %w", row.Row, err)
+			}
+			applyRules(tx, userID, rules, &expense)
+			tags, err := resolveTags(tx, userID, expense.TagNames)
+			if err != nil {
+				return err
+			}
+			expense.Tags = tags
+			expense.TagNames = nil
+			if err := tx.Create(&expense).Error; err != nil {
+				return err
+			}
+			batch.ExpenseCount++
+		}
+
+		return tx.Save(&batch).Error
+	})
+	if err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": "Import failed, nothing was saved: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"batch": batch})
+}
+
+// GetImportBatches lists the user's statement imports, newest first
+func GetImportBatches(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var batches []models.ImportBatch
+	config.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&batches)
+	c.JSON(http.StatusOK, gin.H{"batches": batches})
+}
+
+// RollbackImport removes every expense and income a batch created. Imports
+// whose expenses have since been claimed, or whose income paid a claim, are
+// refused until those claims are removed or their payment undone.
+func RollbackImport(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var batch models.ImportBatch
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&batch).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import not found"})
+		return
+	}
+	if batch.Status != models.ImportStatusCommitted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Import has already been rolled back"})
+		return
+	}
+
+	var claimIDs []uint
+	config.DB.Model(&models.Expense{}).
+		Where("import_batch_id = ? AND user_id = ? AND claim_id IS NOT NULL", batch.ID, userID).
+		Distinct().Pluck("claim_id", &claimIDs)
+	var paidClaimIDs []uint
+	config.DB.Model(&models.Claim{}).
+		Where("user_id = ? AND income_id IN (?)", userID,
+			config.DB.Model(&models.Income{}).Select("id").Where("import_batch_id = ? AND user_id = ?", batch.ID, userID)).
+		Pluck("id", &paidClaimIDs)
+	if len(claimIDs) > 0 || len(paidClaimIDs) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":          "Imported transactions are linked to reimbursement claims; delete those claims or undo their reimbursement first",
+			"claim_ids":      claimIDs,
+			"paid_claim_ids": paidClaimIDs,
+		})
+		return
+	}
+
+	var expensesRemoved, incomesRemoved int64
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("import_batch_id = ? AND user_id = ?", batch.ID, userID).Delete(&models.Expense{})
+		if result.Error != nil {
+			return result.Error
+		}
+		expensesRemoved = result.RowsAffected
+
+		result = tx.Where("import_batch_id = ? AND user_id = ?", batch.ID, userID).Delete(&models.Income{})
+		if result.Error != nil {
+			return result.Error
+		}
+		incomesRemoved = result.RowsAffected
+
+		now := time.Now()
+		batch.Status = models.ImportStatusRolledBack
+		batch.RolledBackAt = &now
+		return tx.Save(&batch).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"batch":            batch,
+		"expenses_removed": expensesRemoved,
+		"incomes_removed":  incomesRemoved,
+	})
+}
+
+// GetImportMappings lists the user's saved statement layouts
+func GetImportMappings(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var mappings []models.ImportMapping
+	config.DB.Where("user_id = ?", userID).Order("name").Find(&mappings)
+	c.JSON(http.StatusOK, gin.H{"mappings": mappings})
+}
+
+// CreateImportMapping saves a statement layout for a bank
+func CreateImportMapping(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var mapping models.ImportMapping
+	if err := c.ShouldBindJSON(&mapping); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mapping.Name = strings.TrimSpace(mapping.Name)
+	if mapping.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mapping name is required"})
+		return
+	}
+
+	mapping.ID = 0
+	mapping.UserID = userID
+	if err := config.DB.Create(&mapping).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"mapping": mapping})
+}
+
+// UpdateImportMapping replaces a saved statement layout
+func UpdateImportMapping(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var mapping models.ImportMapping
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&mapping).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import mapping not found"})
+		return
+	}
+
+	var updateData models.ImportMapping
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if name := strings.TrimSpace(updateData.Name); name != "" {
+		mapping.Name = name
+	}
+	mapping.DateColumn = updateData.DateColumn
+	mapping.NarrationColumn = updateData.NarrationColumn
+	mapping.DebitColumn = updateData.DebitColumn
+	mapping.CreditColumn = updateData.CreditColumn
+	mapping.AmountColumn = updateData.AmountColumn
+	mapping.DateFormat = updateData.DateFormat
+	mapping.HeaderRow = updateData.HeaderRow
+
+	config.DB.Save(&mapping)
+	c.JSON(http.StatusOK, gin.H{"mapping": mapping})
+}
+
+// DeleteImportMapping removes a saved statement layout
+func DeleteImportMapping(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var mapping models.ImportMapping
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&mapping).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import mapping not found"})
+		return
+	}
+
+	config.DB.Delete(&mapping)
+	c.JSON(http.StatusOK, gin.H{"message": "Import mapping deleted successfully"})
+}
@@ -0,0 +1,518 @@
+package controllers
+
+import (
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// prepareIncome links an income to its category and account and converts it
+// into the user's base currency
+func prepareIncome(db *gorm.DB, userID uint, income *models.Income) error {
+	// Uncategorized income, e.g. every imported credit, needs no lookup
+	var category *models.Category
+	if (income.CategoryID != nil && *income.CategoryID != 0) || strings.TrimSpace(income.Category) != "" {
+		var err error
+		if category, err = resolveCategory(db, userID, models.CategoryKindIncome, income.CategoryID, income.Category); err != nil {
+			return err
+		}
+	}
+	if category != nil {
+		income.CategoryID = &category.ID
+		income.Category = category.Name
+	} else {
+		income.CategoryID = nil
+		income.Category = ""
+	}
+
+	if income.AccountID != nil && *income.AccountID != 0 {
+		account, err := findActiveAccount(db, userID, *income.AccountID)
+		if err != nil {
+			return err
+		}
+		if income.Currency == "" {
+			income.Currency = account.Currency
+		}
+	} else {
+		income.AccountID = nil
+	}
+
+	if income.Currency == "" {
+		income.Currency = getUserBaseCurrency(userID)
+	}
+	currency, err := utils.NormalizeCurrency(income.Currency)
+	if err != nil {
+		return err
+	}
+	income.Currency = currency
+
+	return utils.ConvertIncomeToBase(db, income, getUserBaseCurrency(userID))
+}
+
+// GetIncomes lists the user's income, newest first, optionally within a date range
+func GetIncomes(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+
+	if from := c.Query("from"); from != "" {
+		fromDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("received_at >= ?", fromDate)
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("received_at < ?", toDate.AddDate(0, 0, 1))
+	}
+
+	var incomes []models.Income
+	query.Order("received_at DESC, id DESC").Find(&incomes)
+	c.JSON(http.StatusOK, gin.H{"incomes": incomes})
+}
+
+// CreateIncome records money received
+func CreateIncome(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var income models.Income
+	if err := c.ShouldBindJSON(&income); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if income.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+		return
+	}
+
+	income.ID = 0
+	income.UserID = userID
+	income.RecurringIncomeID = nil
+	income.ImportBatchID = nil
+	if income.ReceivedAt.IsZero() {
+		income.ReceivedAt = time.Now()
+	}
+
+	if err := prepareIncome(config.DB, userID, &income); err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := config.DB.Create(&income).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Point out claims this may be the reimbursement for
+	claimMatches, err := openClaimMatches(userID, []models.Income{income})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"income": income, "claim_matches": claimMatches})
+}
+
+// UpdateIncome edits a recorded income
+func UpdateIncome(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var income models.Income
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&income).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Income not found or unauthorized"})
+		return
+	}
+
+	var updateData models.Income
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if updateData.Amount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+		return
+	}
+
+	if updateData.Title != "" {
+		income.Title = updateData.Title
+	}
+	if updateData.Amount != 0 {
+		income.Amount = updateData.Amount
+	}
+	if updateData.Currency != "" {
+		income.Currency = updateData.Currency
+	}
+	if updateData.CategoryID != nil || updateData.Category != "" {
+		income.CategoryID = updateData.CategoryID
+		income.Category = updateData.Category
+	}
+	if updateData.Description != "" {
+		income.Description = updateData.Description
+	}
+	if !updateData.ReceivedAt.IsZero() {
+		income.ReceivedAt = updateData.ReceivedAt
+	}
+	if updateData.AccountID != nil {
+		income.AccountID = updateData.AccountID
+	}
+
+	if err := prepareIncome(config.DB, userID, &income); err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	config.DB.Save(&income)
+	c.JSON(http.StatusOK, gin.H{"income": income})
+}
+
+// DeleteIncome removes a recorded income
+func DeleteIncome(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var income models.Income
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&income).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Income not found or unauthorized"})
+		return
+	}
+
+	// Claims this income paid back go back to waiting for payment
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var claimIDs []uint
+		if err := tx.Model(&models.Claim{}).Where("income_id = ?", income.ID).Pluck("id", &claimIDs).Error; err != nil {
+			return err
+		}
+		for _, claimID := range claimIDs {
+			if err := setClaimStatus(tx, claimID, models.ReimbursementClaimed, nil, nil); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&income).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Income deleted successfully"})
+}
+
+// GetRecurringIncomes lists the user's recurring income rules
+func GetRecurringIncomes(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var rules []models.RecurringIncome
+	config.DB.Where("user_id = ?", userID).Order("next_due_at ASC NULLS LAST, id").Find(&rules)
+	c.JSON(http.StatusOK, gin.H{"recurring_incomes": rules})
+}
+
+// CreateRecurringIncome adds a rule such as a monthly salary
+func CreateRecurringIncome(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var rule models.RecurringIncome
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if rule.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+		return
+	}
+	if rule.Interval == 0 {
+		rule.Interval = 1
+	}
+	if rule.StartDate.IsZero() {
+		rule.StartDate = time.Now()
+	}
+	if err := utils.ValidateSchedule(rule.Schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate the template the same way a one-off income would be
+	template := models.Income{
+		Title: rule.Title, Amount: rule.Amount, Currency: rule.Currency,
+		CategoryID: rule.CategoryID, Category: rule.Category, AccountID: rule.AccountID,
+		ReceivedAt: rule.StartDate,
+	}
+	if err := prepareIncome(config.DB, userID, &template); err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	rule.ID = 0
+	rule.UserID = userID
+	rule.Currency = template.Currency
+	rule.CategoryID = template.CategoryID
+	rule.Category = template.Category
+	rule.AccountID = template.AccountID
+	utils.InitSchedule(&rule.Schedule)
+
+	if err := config.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"recurring_income": rule})
+}
+
+// applyScheduleUpdate applies the schedule fields of a rule update. Changing
+// the frequency, interval, day or start date re-anchors the schedule from the
+// next due date (or the start_date sent); other changes only re-evaluate its
+// limits. Resuming a paused rule skips what fell due while it was paused.
+func applyScheduleUpdate(schedule *models.Schedule, req models.UpdateRecurringRequest, sent map[string]bool) error {
+	if sent["end_date"] {
+		schedule.EndDate = req.EndDate
+	}
+	if req.MaxOccurrences != nil {
+		schedule.MaxOccurrences = *req.MaxOccurrences
+	}
+
+	patternChanged := false
+	if req.Frequency != nil && *req.Frequency != schedule.Frequency {
+		schedule.Frequency = *req.Frequency
+		patternChanged = true
+	}
+	if req.Interval != nil && *req.Interval != schedule.Interval {
+		schedule.Interval = *req.Interval
+		patternChanged = true
+	}
+	if req.DayOfMonth != nil && *req.DayOfMonth != schedule.DayOfMonth {
+		schedule.DayOfMonth = *req.DayOfMonth
+		patternChanged = true
+	}
+	if req.StartDate != nil && !req.StartDate.Equal(schedule.StartDate) {
+		patternChanged = true
+	}
+
+	if err := utils.ValidateSchedule(*schedule); err != nil {
+		return err
+	}
+
+	if patternChanged {
+		start := time.Now()
+		if req.StartDate != nil {
+			start = *req.StartDate
+		} else if schedule.NextDueAt != nil {
+			start = *schedule.NextDueAt
+		}
+		utils.RescheduleFrom(schedule, start)
+	} else {
+		// Re-evaluate whether the new limits end (or reopen) the schedule
+		utils.RefreshSchedule(schedule)
+	}
+
+	if req.IsPaused != nil && *req.IsPaused != schedule.IsPaused {
+		schedule.IsPaused = *req.IsPaused
+		if !schedule.IsPaused {
+			utils.ClearScheduleFailures(schedule)
+			utils.SkipOccurrencesUntil(schedule, time.Now())
+		}
+	}
+	return nil
+}
+
+// UpdateRecurringIncome edits the fields sent of a rule. Changes apply to future
+// occurrences only; income already generated is left untouched.
+func UpdateRecurringIncome(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var rule models.RecurringIncome
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring income not found"})
+		return
+	}
+
+	var req models.UpdateRecurringRequest
+	sent, err := bindJSONFields(c, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Title != nil {
+		rule.Title = *req.Title
+	}
+	if req.Amount != nil {
+		if *req.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+			return
+		}
+		rule.Amount = *req.Amount
+	}
+	if req.Currency != nil {
+		rule.Currency = *req.Currency
+	}
+	if sent["category_id"] || sent["category"] {
+		rule.CategoryID = req.CategoryID
+		rule.Category = ""
+		if req.Category != nil {
+			rule.Category = *req.Category
+		}
+	}
+	if req.Description != nil {
+		rule.Description = *req.Description
+	}
+	if sent["account_id"] {
+		rule.AccountID = req.AccountID
+	}
+
+	if err := applyScheduleUpdate(&rule.Schedule, req, sent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate the template the same way a one-off income would be
+	template := models.Income{
+		Title: rule.Title, Amount: rule.Amount, Currency: rule.Currency,
+		CategoryID: rule.CategoryID, Category: rule.Category, AccountID: rule.AccountID,
+		ReceivedAt: time.Now(),
+	}
+	if err := prepareIncome(config.DB, userID, &template); err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	rule.Currency = template.Currency
+	rule.CategoryID = template.CategoryID
+	rule.Category = template.Category
+	rule.AccountID = template.AccountID
+
+	if err := config.DB.Save(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recurring_income": rule})
+}
+
+// DeleteRecurringIncome stops a rule. Income it already generated is kept.
+func DeleteRecurringIncome(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var rule models.RecurringIncome
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring income not found"})
+		return
+	}
+
+	config.DB.Delete(&rule)
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring income deleted successfully"})
+}
+
+// lockDueRecurringRule reloads a recurring rule into rule and locks its row
+// for the rest of tx, so overlapping runs can't generate the same occurrences.
+// It reports false when another run holds the lock or has already brought the
+// rule up to date.
+func lockDueRecurringRule(tx *gorm.DB, rule interface{}, id uint, now time.Time) (bool, error) {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("id = ? AND next_due_at IS NOT NULL AND next_due_at <= ? AND is_paused = ?", id, now, false).
+		Take(rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// MaterializeRecurringIncome creates Income rows for every rule occurrence due
+// up to `now`. It is safe to run repeatedly, even from overlapping runs: each
+// rule is locked while it is generated, so each occurrence is generated once.
+// A rule that keeps failing is paused with the error recorded.
+func MaterializeRecurringIncome(now time.Time) (int, error) {
+	var rules []models.RecurringIncome
+	if err := config.DB.Where("next_due_at IS NOT NULL AND next_due_at <= ? AND is_paused = ?", now, false).
+		Find(&rules).Error; err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, rule := range rules {
+		loaded := rule
+		generated := 0
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			due, err := lockDueRecurringRule(tx, &rule, loaded.ID, now)
+			if err != nil || !due {
+				return err
+			}
+			for rule.NextDueAt != nil && !rule.NextDueAt.After(now) {
+				income := models.Income{
+					UserID:            rule.UserID,
+					Title:             rule.Title,
+					Amount:            rule.Amount,
+					Currency:          rule.Currency,
+					CategoryID:        rule.CategoryID,
+					Category:          rule.Category,
+					Description:       rule.Description,
+					ReceivedAt:        *rule.NextDueAt,
+					AccountID:         rule.AccountID,
+					RecurringIncomeID: &rule.ID,
+				}
+				if err := prepareIncome(tx, rule.UserID, &income); err != nil {
+					return err
+				}
+				if err := tx.Create(&income).Error; err != nil {
+					return err
+				}
+				generated++
+				utils.AdvanceSchedule(&rule.Schedule)
+			}
+			utils.ClearScheduleFailures(&rule.Schedule)
+			return tx.Save(&rule).Error
+		})
+		if err != nil {
+			log.Printf("❌ Failed to generate income for recurring rule %d: %v", rule.ID, err)
+			recordRecurringFailure(&models.RecurringIncome{}, rule.ID, loaded.Schedule, err)
+			continue
+		}
+		created += generated
+	}
+
+	return created, nil
+}
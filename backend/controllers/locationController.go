@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultNearbyRadius = 500.0   // meters
+	maxNearbyRadius     = 50000.0 // meters
+	maxPlaceResults     = 50
+)
+
+// placeGridPrecision rounds coordinates to about 100 m for grouping unnamed
+// spots; named places use a coarser ~1 km cell so chains in different parts
+// of town stay apart
+const (
+	placeGridPrecision      = 1000.0
+	namedPlaceGridPrecision = 100.0
+)
+
+// locationQuery is a center point and radius parsed from lat, lng and radius
+type locationQuery struct {
+	Latitude  float64
+	Longitude float64
+	Radius    float64
+}
+
+// parseLocationQuery reads ?lat=&lng=&radius= (radius in meters). It returns
+// nil when no point was given and required is false.
+func parseLocationQuery(c *gin.Context, required bool) (*locationQuery, error) {
+	if c.Query("lat") == "" && c.Query("lng") == "" {
+		if required {
+			return nil, errors.New("lat and lng are required")
+		}
+		return nil, nil
+	}
+
+	latitude, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	longitude, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		return nil, errors.New("lat and lng must be numbers")
+	}
+	if err := utils.ValidateCoordinates(&latitude, &longitude); err != nil {
+		return nil, err
+	}
+
+	radius := defaultNearbyRadius
+	if value := c.Query("radius"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > maxNearbyRadius {
+			return nil, fmt.Errorf("radius must be between 1 and %.0f meters", maxNearbyRadius)
+		}
+		radius = parsed
+	}
+
+	return &locationQuery{Latitude: latitude, Longitude: longitude, Radius: radius}, nil
+}
+
+// withinBoundingBox narrows a query to the box around a location, which the
+// location index can serve; exact distances are checked afterwards
+func withinBoundingBox(query *gorm.DB, location locationQuery) *gorm.DB {
+	minLat, maxLat, minLon, maxLon := utils.BoundingBox(location.Latitude, location.Longitude, location.Radius)
+	return query.Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", minLat, maxLat, minLon, maxLon)
+}
+
+// GetNearbyExpenses lists expenses logged within a radius of a point, closest
+// first, with the total spent there. Takes the same filters as GET /expenses.
+func GetNearbyExpenses(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	location, err := parseLocationQuery(c, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := filterExpenses(c, withinBoundingBox(config.DB.Where("user_id = ?", userID), *location), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var candidates []models.Expense
+	preloadExpenseDetails(query).Find(&candidates)
+
+	baseCurrency := getUserBaseCurrency(userID)
+	var total models.Money
+	nearby := []models.NearbyExpense{}
+	for _, expense := range candidates {
+		distance := utils.DistanceMeters(location.Latitude, location.Longitude, *expense.Latitude, *expense.Longitude)
+		if distance > location.Radius {
+			continue
+		}
+		nearby = append(nearby, models.NearbyExpense{Expense: expense, DistanceMeters: math.Round(distance)})
+		if expense.BaseCurrency == baseCurrency {
+			total += expense.BaseAmount
+		}
+	}
+	sort.SliceStable(nearby, func(i, j int) bool {
+		if nearby[i].DistanceMeters != nearby[j].DistanceMeters {
+			return nearby[i].DistanceMeters < nearby[j].DistanceMeters
+		}
+		return nearby[i].SpentAt.After(nearby[j].SpentAt)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"expenses": nearby,
+		"count":    len(nearby),
+		"total":    total,
+		"currency": baseCurrency,
+		"radius":   location.Radius,
+	})
+}
+
+// placeKey groups an expense by its place name within a ~1 km cell, or by a
+// ~100 m cell when it has no name
+func placeKey(expense models.Expense) string {
+	name := models.NormalizeCategoryName(expense.PlaceName)
+	precision := placeGridPrecision
+	if name != "" {
+		precision = namedPlaceGridPrecision
+	}
+	return fmt.Sprintf("%s|%.0f|%.0f", name,
+		math.Round(*expense.Latitude*precision), math.Round(*expense.Longitude*precision))
+}
+
+// GetPlaceSpending groups located spending by place, biggest first. Pass
+// lat/lng/radius to limit it to an area, e.g. around the office, plus any of
+// the GET /expenses filters.
+func GetPlaceSpending(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	location, err := parseLocationQuery(c, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	baseCurrency := getUserBaseCurrency(userID)
+	query := config.DB.Where("user_id = ? AND base_currency = ? AND latitude IS NOT NULL AND longitude IS NOT NULL",
+		userID, baseCurrency)
+	if location != nil {
+		query = withinBoundingBox(query, *location)
+	}
+	query, err = filterExpenses(c, query, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expenses []models.Expense
+	query.Select("id, title, base_amount, spent_at, latitude, longitude, place_name").Find(&expenses)
+
+	type placeAccumulator struct {
+		models.PlaceSpending
+		latSum, lonSum float64
+		names          map[string]int
+	}
+	places := make(map[string]*placeAccumulator)
+	for _, expense := range expenses {
+		if location != nil && utils.DistanceMeters(location.Latitude, location.Longitude,
+			*expense.Latitude, *expense.Longitude) > location.Radius {
+			continue
+		}
+
+		key := placeKey(expense)
+		place, ok := places[key]
+		if !ok {
+			place = &placeAccumulator{names: make(map[string]int)}
+			places[key] = place
+		}
+		place.Total += expense.BaseAmount
+		place.Count++
+		place.latSum += *expense.Latitude
+		place.lonSum += *expense.Longitude
+		if expense.SpentAt.After(place.LastSpentAt) {
+			place.LastSpentAt = expense.SpentAt
+		}
+		if name := strings.TrimSpace(expense.PlaceName); name != "" {
+			place.names[name]++
+		}
+	}
+
+	var total models.Money
+	results := make([]models.PlaceSpending, 0, len(places))
+	for _, place := range places {
+		// Show the spelling used most often for the place
+		for name, count := range place.names {
+			if count > place.names[place.PlaceName] || (count == place.names[place.PlaceName] && name < place.PlaceName) {
+				place.PlaceName = name
+			}
+		}
+		place.Latitude = place.latSum / float64(place.Count)
+		place.Longitude = place.lonSum / float64(place.Count)
+		results = append(results, place.PlaceSpending)
+		total += place.Total
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Total != results[j].Total {
+			return results[i].Total > results[j].Total
+		}
+		return results[i].LastSpentAt.After(results[j].LastSpentAt)
+	})
+	if len(results) > maxPlaceResults {
+		results = results[:maxPlaceResults]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"places": results, "total": total, "currency": baseCurrency})
+}
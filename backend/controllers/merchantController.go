@@ -0,0 +1,443 @@
+package controllers
+
+import (
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// findUserMerchant loads one of the user's merchants or writes the error response
+func findUserMerchant(c *gin.Context, userID uint, id interface{}) (*models.Merchant, bool) {
+	var merchant models.Merchant
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&merchant).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Merchant not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &merchant, true
+}
+
+// expenseMerchant returns the merchant the client chose, or else the one a
+// title or VPA normalizes to (creating it on first sight)
+func expenseMerchant(db *gorm.DB, userID uint, merchantID *uint, title, vpa string) (*models.Merchant, error) {
+	if merchantID != nil && *merchantID != 0 {
+		var merchant models.Merchant
+		if err := db.Where("id = ? AND user_id = ?", *merchantID, userID).First(&merchant).Error; err != nil {
+			return nil, errors.New("merchant not found")
+		}
+		return &merchant, nil
+	}
+	return utils.ResolveMerchant(db, userID, title, vpa)
+}
+
+// assignExpenseMerchant links a new expense to its merchant. The merchant's
+// default category fills in when the expense has none.
+func assignExpenseMerchant(db *gorm.DB, userID uint, expense *models.Expense) error {
+	merchant, err := expenseMerchant(db, userID, expense.MerchantID, expense.Title, expense.VPA)
+	if err != nil {
+		return err
+	}
+	expense.VPA = ""
+
+	if merchant == nil {
+		expense.MerchantID = nil
+		return nil
+	}
+	expense.MerchantID = &merchant.ID
+
+	if expense.CategoryID == nil && merchant.DefaultCategoryID != nil {
+		category, err := resolveCategory(db, userID, models.CategoryKindExpense, merchant.DefaultCategoryID, "")
+		if err == nil && category != nil {
+			expense.CategoryID = &category.ID
+			expense.Category = category.Name
+		}
+	}
+	return nil
+}
+
+// applyMerchantDefaultCategory validates and sets a merchant's default category
+func applyMerchantDefaultCategory(db *gorm.DB, userID uint, merchant *models.Merchant, req models.MerchantRequest) error {
+	if req.DefaultCategoryID != nil && *req.DefaultCategoryID == 0 {
+		merchant.DefaultCategoryID = nil
+		merchant.DefaultCategory = ""
+		return nil
+	}
+	if req.DefaultCategoryID == nil && req.DefaultCategory == "" {
+		return nil
+	}
+
+	category, err := resolveCategory(db, userID, models.CategoryKindExpense, req.DefaultCategoryID, req.DefaultCategory)
+	if err != nil {
+		return err
+	}
+	merchant.DefaultCategoryID = &category.ID
+	merchant.DefaultCategory = category.Name
+	return nil
+}
+
+// merchantTotals sums base-currency spending per merchant
+func merchantTotals(userID uint, merchantIDs []uint) map[uint]models.MerchantSummary {
+	type totalRow struct {
+		MerchantID  uint
+		Total       models.Money
+		Count       int64
+		LastSpentAt *time.Time
+	}
+	var rows []totalRow
+	config.DB.Model(&models.Expense{}).
+		Select("merchant_id, COALESCE(SUM(base_amount), 0) AS total, COUNT(*) AS count, MAX(spent_at) AS last_spent_at").
+		Where("user_id = ? AND base_currency = ? AND merchant_id IN ?", userID, getUserBaseCurrency(userID), merchantIDs).
+		Group("merchant_id").
+		Scan(&rows)
+
+	totals := make(map[uint]models.MerchantSummary, len(rows))
+	for _, row := range rows {
+		totals[row.MerchantID] = models.MerchantSummary{Total: row.Total, Count: row.Count, LastSpentAt: row.LastSpentAt}
+	}
+	return totals
+}
+
+// GetMerchants lists the user's merchants with spending totals, biggest first.
+// ?q= filters by name.
+func GetMerchants(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if q := models.NormalizeCategoryName(c.Query("q")); q != "" {
+		query = query.Where("normalized_name LIKE ?", "%"+strings.NewReplacer("%", "\\%", "_", "\\_").Replace(q)+"%")
+	}
+	var merchants []models.Merchant
+	query.Order("name").Find(&merchants)
+
+	ids := make([]uint, len(merchants))
+	for i, merchant := range merchants {
+		ids[i] = merchant.ID
+	}
+	totals := merchantTotals(userID, ids)
+
+	summaries := make([]models.MerchantSummary, len(merchants))
+	for i, merchant := range merchants {
+		summary := totals[merchant.ID]
+		summary.Merchant = merchant
+		summaries[i] = summary
+	}
+	// Stable sort keeps alphabetical order among equal totals
+	sort.SliceStable(summaries, func(i, j int) bool { return summaries[i].Total > summaries[j].Total })
+
+	c.JSON(http.StatusOK, gin.H{"merchants": summaries, "currency": getUserBaseCurrency(userID)})
+}
+
+// GetMerchant returns a merchant with its aliases, totals and the last 12 months of spending
+func GetMerchant(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	merchant, ok := findUserMerchant(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+	config.DB.Where("merchant_id = ?", merchant.ID).Order("alias").Find(&merchant.Aliases)
+
+	summary := merchantTotals(userID, []uint{merchant.ID})[merchant.ID]
+	summary.Merchant = *merchant
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -11, 0)
+	var months []monthTotal
+	config.DB.Model(&models.Expense{}).
+		Select("TO_CHAR(spent_at, 'YYYY-MM') AS month, COALESCE(SUM(base_amount), 0) AS total").
+		Where("user_id = ? AND merchant_id = ? AND base_currency = ? AND spent_at >= ?",
+			userID, merchant.ID, getUserBaseCurrency(userID), from).
+		Group("month").Order("month").
+		Scan(&months)
+
+	c.JSON(http.StatusOK, gin.H{"merchant": summary, "months": months, "currency": getUserBaseCurrency(userID)})
+}
+
+// GetMerchantExpenses lists a merchant's expenses, newest first. It takes the
+// same filters as GET /expenses.
+func GetMerchantExpenses(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	merchant, ok := findUserMerchant(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	query, err := filterExpenses(c, config.DB.Where("user_id = ? AND merchant_id = ?", userID, merchant.ID), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expenses []models.Expense
+	preloadExpenseDetails(query).Order("spent_at DESC").Find(&expenses)
+
+	var total models.Money
+	baseCurrency := getUserBaseCurrency(userID)
+	for _, expense := range expenses {
+		if expense.BaseCurrency == baseCurrency {
+			total += expense.BaseAmount
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"merchant": merchant, "expenses": expenses, "total": total, "currency": baseCurrency})
+}
+
+// CreateMerchant adds a merchant with optional aliases and default category
+func CreateMerchant(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.MerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.Join(strings.Fields(req.Name), " ")
+	normalized := models.NormalizeCategoryName(name)
+	if normalized == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Merchant name is required"})
+		return
+	}
+
+	var existing int64
+	config.DB.Model(&models.Merchant{}).Where("user_id = ? AND normalized_name = ?", userID, normalized).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A merchant with this name already exists"})
+		return
+	}
+
+	merchant := models.Merchant{UserID: userID, Name: name, NormalizedName: normalized}
+	if err := applyMerchantDefaultCategory(config.DB, userID, &merchant, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var conflicts []string
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&merchant).Error; err != nil {
+			return err
+		}
+		for _, alias := range append([]string{name}, req.Aliases...) {
+			if !utils.AddMerchantAlias(tx, userID, merchant.ID, alias) && strings.TrimSpace(alias) != "" {
+				conflicts = append(conflicts, alias)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	config.DB.Where("merchant_id = ?", merchant.ID).Order("alias").Find(&merchant.Aliases)
+	c.JSON(http.StatusCreated, gin.H{"merchant": merchant, "alias_conflicts": conflicts})
+}
+
+// UpdateMerchant renames a merchant, changes its default category or adds aliases
+func UpdateMerchant(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	merchant, ok := findUserMerchant(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req models.MerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if name := strings.Join(strings.Fields(req.Name), " "); name != "" {
+		normalized := models.NormalizeCategoryName(name)
+		var existing int64
+		config.DB.Model(&models.Merchant{}).
+			Where("user_id = ? AND normalized_name = ? AND id <> ?", userID, normalized, merchant.ID).Count(&existing)
+		if existing > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another merchant has this name; merge them instead"})
+			return
+		}
+		merchant.Name = name
+		merchant.NormalizedName = normalized
+	}
+	if err := applyMerchantDefaultCategory(config.DB, userID, merchant, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var conflicts []string
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(merchant).Error; err != nil {
+			return err
+		}
+		aliases := req.Aliases
+		if req.Name != "" {
+			aliases = append([]string{merchant.Name}, aliases...)
+		}
+		for _, alias := range aliases {
+			if !utils.AddMerchantAlias(tx, userID, merchant.ID, alias) && strings.TrimSpace(alias) != "" {
+				conflicts = append(conflicts, alias)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	config.DB.Where("merchant_id = ?", merchant.ID).Order("alias").Find(&merchant.Aliases)
+	c.JSON(http.StatusOK, gin.H{"merchant": merchant, "alias_conflicts": conflicts})
+}
+
+// DeleteMerchantAlias stops a title or VPA from mapping to a merchant
+func DeleteMerchantAlias(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	merchant, ok := findUserMerchant(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	result := config.DB.Where("id = ? AND merchant_id = ? AND user_id = ?", c.Param("aliasId"), merchant.ID, userID).
+		Delete(&models.MerchantAlias{})
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alias not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Alias removed successfully"})
+}
+
+// MergeMerchant folds a merchant into another: its expenses and aliases move
+// to the target and the source is removed
+func MergeMerchant(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	source, ok := findUserMerchant(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req models.MergeMerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.TargetID == source.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge a merchant into itself"})
+		return
+	}
+	target, ok := findUserMerchant(c, userID, req.TargetID)
+	if !ok {
+		return
+	}
+
+	var movedExpenses int64
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.Expense{}).Where("merchant_id = ?", source.ID).
+			Updates(map[string]interface{}{"merchant_id": target.ID, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return result.Error
+		}
+		movedExpenses = result.RowsAffected
+
+		if err := tx.Model(&models.MerchantAlias{}).Where("merchant_id = ?", source.ID).
+			Update("merchant_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(source).Error; err != nil {
+			return err
+		}
+
+		// Keep the source's name recognizable once it's gone
+		utils.AddMerchantAlias(tx, userID, target.ID, source.Name)
+		if target.DefaultCategoryID == nil && source.DefaultCategoryID != nil {
+			target.DefaultCategoryID = source.DefaultCategoryID
+			target.DefaultCategory = source.DefaultCategory
+			return tx.Save(target).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	config.DB.Where("merchant_id = ?", target.ID).Order("alias").Find(&target.Aliases)
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Merchants merged successfully",
+		"merchant":       target,
+		"expenses_moved": movedExpenses,
+	})
+}
+
+// DeleteMerchant removes a merchant and its aliases; its expenses are kept unlinked
+func DeleteMerchant(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	merchant, ok := findUserMerchant(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Expense{}).Where("merchant_id = ?", merchant.ID).
+			Updates(map[string]interface{}{"merchant_id": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("merchant_id = ?", merchant.ID).Delete(&models.MerchantAlias{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(merchant).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Merchant deleted successfully"})
+}
@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// receiptContentTypes maps the file types accepted as receipts to their extension
+var receiptContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// receiptURLTTL is how long signed receipt download links stay valid
+const receiptURLTTL = 15 * time.Minute
+
+// receiptMaxBytes reads the upload size limit from RECEIPT_MAX_BYTES, defaulting to 10 MB
+func receiptMaxBytes() int64 {
+	if value := os.Getenv("RECEIPT_MAX_BYTES"); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return 10 << 20
+}
+
+// withReceiptURLs fills in expiring download links for a receipt
+func withReceiptURLs(receipt models.Receipt) models.Receipt {
+	receipt.URL = utils.SignReceiptURL(receipt.ID, "original", receiptURLTTL)
+	if receipt.ThumbnailKey != "" {
+		receipt.ThumbnailURL = utils.SignReceiptURL(receipt.ID, "thumbnail", receiptURLTTL)
+	}
+	return receipt
+}
+
+// randomKey returns a hex string suitable for an unguessable storage key
+func randomKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// UploadReceipt attaches an image or PDF to an expense (multipart field "file")
+func UploadReceipt(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var expense models.Expense
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&expense).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found or unauthorized"})
+		return
+	}
+
+	// Leave headroom for multipart framing around the file itself
+	maxBytes := receiptMaxBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Receipt must be at most %d bytes", maxBytes)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload a receipt in the 'file' field"})
+		return
+	}
+	if fileHeader.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Receipt must be at most %d bytes", maxBytes)})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if int64(len(data)) > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Receipt must be at most %d bytes", maxBytes)})
+		return
+	}
+
+	// Trust the bytes, not the client's Content-Type header
+	contentType := http.DetectContentType(data)
+	ext, ok := receiptContentTypes[contentType]
+	if !ok {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Receipts must be JPEG, PNG, GIF, WebP or PDF", "detected": contentType})
+		return
+	}
+
+	id, err := randomKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	prefix := fmt.Sprintf("receipts/%d/%d/%s", userID, expense.ID, id)
+
+	checksum := sha256.Sum256(data)
+	receipt := models.Receipt{
+		UserID:      userID,
+		ExpenseID:   expense.ID,
+		FileName:    fileHeader.Filename,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Checksum:    hex.EncodeToString(checksum[:]),
+		StorageKey:  prefix + ext,
+	}
+
+	store := utils.GetBlobStore()
+	ctx := c.Request.Context()
+	if err := store.Put(ctx, receipt.StorageKey, bytes.NewReader(data), receipt.Size, contentType); err != nil {
+		log.Printf("❌ Failed to store receipt: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store receipt"})
+		return
+	}
+
+	// Thumbnails are best effort; PDFs and WebP fall back to the original
+	if contentType != "application/pdf" {
+		if thumbnail, err := utils.GenerateThumbnail(data); err == nil {
+			thumbnailKey := prefix + "_thumb.jpg"
+			if err := store.Put(ctx, thumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err == nil {
+				receipt.ThumbnailKey = thumbnailKey
+			} else {
+				log.Printf("⚠️ Failed to store receipt thumbnail: %v", err)
+			}
+		}
+	}
+
+	if err := config.DB.Create(&receipt).Error; err != nil {
+		store.Delete(ctx, receipt.StorageKey)
+		if receipt.ThumbnailKey != "" {
+			store.Delete(ctx, receipt.ThumbnailKey)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"receipt": withReceiptURLs(receipt)})
+}
+
+// GetExpenseReceipts lists an expense's receipts with fresh download links
+func GetExpenseReceipts(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var expense models.Expense
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&expense).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found or unauthorized"})
+		return
+	}
+
+	var receipts []models.Receipt
+	config.DB.Where("expense_id = ? AND user_id = ?", expense.ID, userID).Order("created_at").Find(&receipts)
+	for i := range receipts {
+		receipts[i] = withReceiptURLs(receipts[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"receipts": receipts, "expires_in": int(receiptURLTTL.Seconds())})
+}
+
+// GetReceiptURL returns fresh expiring download links for a receipt
+func GetReceiptURL(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var receipt models.Receipt
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&receipt).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Receipt not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"receipt": withReceiptURLs(receipt), "expires_in": int(receiptURLTTL.Seconds())})
+}
+
+// DownloadReceipt streams a receipt file. It is authorized by the signed,
+// expiring link rather than a JWT so image views can load it directly.
+func DownloadReceipt(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Receipt not found"})
+		return
+	}
+	variant := c.DefaultQuery("variant", "original")
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || !utils.VerifyReceiptURL(uint(id), variant, expires, c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Download link is invalid or has expired"})
+		return
+	}
+
+	var receipt models.Receipt
+	if err := config.DB.First(&receipt, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Receipt not found"})
+		return
+	}
+
+	key, contentType := receipt.StorageKey, receipt.ContentType
+	if variant == "thumbnail" {
+		if receipt.ThumbnailKey == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Receipt has no thumbnail"})
+			return
+		}
+		key, contentType = receipt.ThumbnailKey, "image/jpeg"
+	}
+
+	reader, err := utils.GetBlobStore().Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, utils.ErrBlobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Receipt file is missing"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read receipt"})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Header("X-Content-Type-Options", "nosniff")
+	if variant != "thumbnail" {
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", receipt.FileName))
+	}
+	c.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
+}
+
+// DeleteReceipt removes a receipt and its stored files
+func DeleteReceipt(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var receipt models.Receipt
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&receipt).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Receipt not found"})
+		return
+	}
+
+	store := utils.GetBlobStore()
+	ctx := c.Request.Context()
+	if err := store.Delete(ctx, receipt.StorageKey); err != nil {
+		log.Printf("❌ Failed to delete receipt file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete receipt file"})
+		return
+	}
+	if receipt.ThumbnailKey != "" {
+		if err := store.Delete(ctx, receipt.ThumbnailKey); err != nil {
+			log.Printf("⚠️ Failed to delete receipt thumbnail: %v", err)
+		}
+	}
+
+	config.DB.Unscoped().Delete(&receipt)
+	c.JSON(http.StatusOK, gin.H{"message": "Receipt deleted successfully"})
+}
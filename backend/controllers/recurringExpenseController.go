@@ -0,0 +1,389 @@
+package controllers
+
+import (
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// buildRecurringOccurrence turns a rule into the Expense it generates on a
+// given date, linked to its category and account and converted to base currency
+func buildRecurringOccurrence(db *gorm.DB, rule models.RecurringExpense, dueAt time.Time) (models.Expense, error) {
+	expense := models.Expense{
+		UserID:      rule.UserID,
+		Title:       rule.Title,
+		Amount:      rule.Amount,
+		Currency:    rule.Currency,
+		CategoryID:  rule.CategoryID,
+		Category:    rule.Category,
+		Description: rule.Description,
+		SpentAt:     dueAt,
+		AccountID:   rule.AccountID,
+	}
+	if rule.ID != 0 {
+		expense.RecurringExpenseID = &rule.ID
+	}
+
+	if err := assignExpenseCategory(db, rule.UserID, &expense); err != nil {
+		return expense, err
+	}
+	if err := assignExpenseAccount(db, rule.UserID, &expense); err != nil {
+		return expense, err
+	}
+	if err := applyCurrencyConversion(db, rule.UserID, &expense); err != nil {
+		return expense, err
+	}
+	return expense, nil
+}
+
+// findUserRecurringExpense loads a rule owned by the user, writing a 404 if missing
+func findUserRecurringExpense(c *gin.Context, userID uint) (*models.RecurringExpense, bool) {
+	var rule models.RecurringExpense
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring expense not found"})
+		return nil, false
+	}
+	return &rule, true
+}
+
+// GetRecurringExpenses lists the user's recurring expense rules, next due first
+func GetRecurringExpenses(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var rules []models.RecurringExpense
+	config.DB.Where("user_id = ?", userID).Order("next_due_at ASC NULLS LAST, id").Find(&rules)
+	c.JSON(http.StatusOK, gin.H{"recurring_expenses": rules})
+}
+
+// CreateRecurringExpense adds a rule such as monthly rent or a yearly subscription
+func CreateRecurringExpense(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var rule models.RecurringExpense
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if rule.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+		return
+	}
+	if rule.Interval == 0 {
+		rule.Interval = 1
+	}
+	if rule.StartDate.IsZero() {
+		rule.StartDate = time.Now()
+	}
+	if err := utils.ValidateSchedule(rule.Schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule.ID = 0
+	rule.UserID = userID
+
+	// Validate the template the same way a one-off expense would be
+	template, err := buildRecurringOccurrence(config.DB, rule, rule.StartDate)
+	if err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	rule.Currency = template.Currency
+	rule.CategoryID = template.CategoryID
+	rule.Category = template.Category
+	rule.AccountID = template.AccountID
+	utils.InitSchedule(&rule.Schedule)
+
+	if err := config.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"recurring_expense": rule})
+}
+
+// UpdateRecurringExpense edits the fields sent of a rule. Changes apply to
+// future occurrences only; expenses already generated are left as they are.
+// Changing the frequency, interval, day or start date re-anchors the schedule
+// from the next due date (or the start_date sent).
+func UpdateRecurringExpense(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	rule, ok := findUserRecurringExpense(c, userID)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateRecurringRequest
+	sent, err := bindJSONFields(c, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Title != nil {
+		rule.Title = *req.Title
+	}
+	if req.Amount != nil {
+		if *req.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than zero"})
+			return
+		}
+		rule.Amount = *req.Amount
+	}
+	if req.Currency != nil {
+		rule.Currency = *req.Currency
+	}
+	if sent["category_id"] || sent["category"] {
+		rule.CategoryID = req.CategoryID
+		rule.Category = ""
+		if req.Category != nil {
+			rule.Category = *req.Category
+		}
+	}
+	if req.Description != nil {
+		rule.Description = *req.Description
+	}
+	if sent["account_id"] {
+		rule.AccountID = req.AccountID
+	}
+
+	if err := applyScheduleUpdate(&rule.Schedule, req, sent); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := buildRecurringOccurrence(config.DB, *rule, time.Now())
+	if err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	rule.Currency = template.Currency
+	rule.CategoryID = template.CategoryID
+	rule.Category = template.Category
+	rule.AccountID = template.AccountID
+
+	if err := config.DB.Save(rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recurring_expense": rule})
+}
+
+// SkipRecurringExpense skips the next occurrence without generating an expense
+func SkipRecurringExpense(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	rule, ok := findUserRecurringExpense(c, userID)
+	if !ok {
+		return
+	}
+
+	if rule.NextDueAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Recurring expense has no upcoming occurrence"})
+		return
+	}
+
+	skipped := *rule.NextDueAt
+	utils.AdvanceSchedule(&rule.Schedule)
+	config.DB.Save(rule)
+
+	c.JSON(http.StatusOK, gin.H{"skipped": skipped, "recurring_expense": rule})
+}
+
+// PauseRecurringExpense stops a rule from generating expenses until resumed
+func PauseRecurringExpense(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	rule, ok := findUserRecurringExpense(c, userID)
+	if !ok {
+		return
+	}
+
+	rule.IsPaused = true
+	config.DB.Save(rule)
+	c.JSON(http.StatusOK, gin.H{"recurring_expense": rule})
+}
+
+// ResumeRecurringExpense restarts a paused rule. Occurrences that fell due while
+// it was paused are skipped rather than back-filled.
+func ResumeRecurringExpense(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	rule, ok := findUserRecurringExpense(c, userID)
+	if !ok {
+		return
+	}
+
+	skipped := 0
+	if rule.IsPaused {
+		rule.IsPaused = false
+		utils.ClearScheduleFailures(&rule.Schedule)
+		skipped = utils.SkipOccurrencesUntil(&rule.Schedule, time.Now())
+		config.DB.Save(rule)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"skipped_occurrences": skipped, "recurring_expense": rule})
+}
+
+// DeleteRecurringExpense removes a rule. Expenses it already generated are kept.
+func DeleteRecurringExpense(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	rule, ok := findUserRecurringExpense(c, userID)
+	if !ok {
+		return
+	}
+
+	config.DB.Delete(rule)
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring expense deleted successfully"})
+}
+
+// GetUpcomingCharges lists recurring expenses falling due in the next `days`
+// days (default 30), with the total per currency
+func GetUpcomingCharges(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	days := 30
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 366 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 366"})
+			return
+		}
+		days = parsed
+	}
+
+	now := time.Now()
+	until := now.AddDate(0, 0, days)
+
+	var rules []models.RecurringExpense
+	config.DB.Where("user_id = ? AND is_paused = ? AND next_due_at IS NOT NULL AND next_due_at <= ?", userID, false, until).
+		Find(&rules)
+
+	charges := []models.UpcomingCharge{}
+	totals := make(map[string]models.Money)
+	for _, rule := range rules {
+		for _, dueAt := range utils.UpcomingOccurrences(rule.Schedule, until, days+1) {
+			charges = append(charges, models.UpcomingCharge{
+				RecurringExpenseID: rule.ID,
+				Title:              rule.Title,
+				Amount:             rule.Amount,
+				Currency:           rule.Currency,
+				Category:           rule.Category,
+				DueAt:              dueAt,
+			})
+			totals[rule.Currency] += rule.Amount
+		}
+	}
+
+	sort.SliceStable(charges, func(i, j int) bool {
+		return charges[i].DueAt.Before(charges[j].DueAt)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":    now.Format("2006-01-02"),
+		"to":      until.Format("2006-01-02"),
+		"charges": charges,
+		"totals":  totals,
+	})
+}
+
+// recordRecurringFailure stores a failed generation run on a rule, pausing it
+// once it has failed utils.MaxScheduleFailures times in a row
+func recordRecurringFailure(model interface{}, id uint, schedule models.Schedule, err error) {
+	if utils.RecordScheduleFailure(&schedule, err) {
+		log.Printf("⏸️ Paused recurring rule %d after %d failed runs", id, schedule.FailureCount)
+	}
+	config.DB.Model(model).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"failure_count": schedule.FailureCount,
+		"last_error":    schedule.LastError,
+		"is_paused":     schedule.IsPaused,
+	})
+}
+
+// MaterializeRecurringExpenses creates Expense rows for every rule occurrence
+// due up to `now`. It is safe to run repeatedly, even from overlapping runs:
+// each rule is locked while it is generated, so each occurrence is generated
+// once. A rule that keeps failing is paused with the error recorded.
+func MaterializeRecurringExpenses(now time.Time) (int, error) {
+	var rules []models.RecurringExpense
+	if err := config.DB.Where("next_due_at IS NOT NULL AND next_due_at <= ? AND is_paused = ?", now, false).
+		Find(&rules).Error; err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, rule := range rules {
+		loaded := rule
+		generated := 0
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			due, err := lockDueRecurringRule(tx, &rule, loaded.ID, now)
+			if err != nil || !due {
+				return err
+			}
+			for rule.NextDueAt != nil && !rule.NextDueAt.After(now) {
+				expense, err := buildRecurringOccurrence(tx, rule, *rule.NextDueAt)
+				if err != nil {
+					return err
+				}
+				if err := tx.Create(&expense).Error; err != nil {
+					return err
+				}
+				generated++
+				utils.AdvanceSchedule(&rule.Schedule)
+			}
+			utils.ClearScheduleFailures(&rule.Schedule)
+			return tx.Save(&rule).Error
+		})
+		if err != nil {
+			log.Printf("❌ Failed to generate expenses for recurring rule %d: %v", rule.ID, err)
+			recordRecurringFailure(&models.RecurringExpense{}, rule.ID, loaded.Schedule, err)
+			continue
+		}
+		created += generated
+	}
+
+	return created, nil
+}
@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"bytes"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// monthTotal is one row of a per-month aggregate query
+type monthTotal struct {
+	Month string
+	Total models.Money
+}
+
+// GetCashFlow reports income, expense, net and savings rate per month.
+// from/to are YYYY-MM and default to the last 12 months including this one.
+func GetCashFlow(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	from := to.AddDate(0, -11, 0)
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01", value, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from month, expected YYYY-MM"})
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01", value, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to month, expected YYYY-MM"})
+			return
+		}
+		to = parsed
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+	end := to.AddDate(0, 1, 0)
+
+	baseCurrency := getUserBaseCurrency(userID)
+
+	var incomeRows, expenseRows []monthTotal
+	config.DB.Model(&models.Income{}).
+		Select("TO_CHAR(received_at, 'YYYY-MM') AS month, COALESCE(SUM(base_amount), 0) AS total").
+		Where("user_id = ? AND base_currency = ? AND received_at >= ? AND received_at < ?", userID, baseCurrency, from, end).
+		Group("month").
+		Scan(&incomeRows)
+	config.DB.Model(&models.Expense{}).
+		Select("TO_CHAR(spent_at, 'YYYY-MM') AS month, COALESCE(SUM(base_amount), 0) AS total").
+		Where("user_id = ? AND base_currency = ? AND spent_at >= ? AND spent_at < ?", userID, baseCurrency, from, end).
+		Group("month").
+		Scan(&expenseRows)
+
+	incomeByMonth := make(map[string]models.Money)
+	for _, row := range incomeRows {
+		incomeByMonth[row.Month] = row.Total
+	}
+	expenseByMonth := make(map[string]models.Money)
+	for _, row := range expenseRows {
+		expenseByMonth[row.Month] = row.Total
+	}
+
+	// Emit every month in range, including ones with no activity
+	var months []models.CashFlowMonth
+	var totalIncome, totalExpense models.Money
+	for month := from; month.Before(end); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		entry := models.CashFlowMonth{
+			Month:   key,
+			Income:  incomeByMonth[key],
+			Expense: expenseByMonth[key],
+		}
+		entry.Net = entry.Income - entry.Expense
+		entry.SavingsRate = entry.Net.Percent(entry.Income)
+		months = append(months, entry)
+
+		totalIncome += entry.Income
+		totalExpense += entry.Expense
+	}
+
+	net := totalIncome - totalExpense
+	c.JSON(http.StatusOK, gin.H{
+		"currency": baseCurrency,
+		"months":   months,
+		"totals": gin.H{
+			"income":       totalIncome,
+			"expense":      totalExpense,
+			"net":          net,
+			"savings_rate": net.Percent(totalIncome),
+		},
+	})
+}
+
+// reportTopMerchants is how many payees the monthly report lists
+const reportTopMerchants = 10
+
+// buildMonthlyReport gathers a month's income, spending, budgets and
+// transactions in the user's base currency
+func buildMonthlyReport(userID uint, month time.Time) (models.MonthlyReport, error) {
+	end := month.AddDate(0, 1, 0)
+	baseCurrency := getUserBaseCurrency(userID)
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return models.MonthlyReport{}, err
+	}
+
+	report := models.MonthlyReport{
+		Month:       month.Format("2006-01"),
+		UserName:    user.Name,
+		Currency:    baseCurrency,
+		GeneratedAt: time.Now(),
+	}
+
+	var income int64
+	config.DB.Model(&models.Income{}).
+		Where("user_id = ? AND base_currency = ? AND received_at >= ? AND received_at < ?", userID, baseCurrency, month, end).
+		Select("COALESCE(SUM(base_amount), 0)").Row().Scan(&income)
+	report.Income = models.Money(income)
+
+	if err := preloadExpenseDetails(config.DB).
+		Where("user_id = ? AND base_currency = ? AND spent_at >= ? AND spent_at < ?", userID, baseCurrency, month, end).
+		Order("spent_at, id").Find(&report.Transactions).Error; err != nil {
+		return models.MonthlyReport{}, err
+	}
+
+	// Linked merchants group spending; unlinked expenses fall back to their title
+	var merchantIDs []uint
+	for _, expense := range report.Transactions {
+		if expense.MerchantID != nil {
+			merchantIDs = append(merchantIDs, *expense.MerchantID)
+		}
+	}
+	merchantNames := make(map[uint]string)
+	if len(merchantIDs) > 0 {
+		var linked []models.Merchant
+		config.DB.Where("id IN ?", merchantIDs).Find(&linked)
+		for _, merchant := range linked {
+			merchantNames[merchant.ID] = merchant.Name
+		}
+	}
+
+	categories := make(map[string]*models.CategoryTotal)
+	merchants := make(map[string]*models.MerchantTotal)
+	for _, expense := range report.Transactions {
+		report.Expense += expense.BaseAmount
+
+		// Itemized expenses are split across the categories of their lines
+		shares := []models.ExpenseItem{{CategoryID: expense.CategoryID, Category: expense.Category, BaseAmount: expense.BaseAmount}}
+		if len(expense.Items) > 0 {
+			shares = expense.Items
+		}
+		for _, share := range shares {
+			categoryKey := share.Category
+			if share.CategoryID != nil {
+				categoryKey = fmt.Sprint(*share.CategoryID)
+			}
+			category, ok := categories[categoryKey]
+			if !ok {
+				category = &models.CategoryTotal{CategoryID: share.CategoryID, Category: share.Category}
+				categories[categoryKey] = category
+			}
+			category.Total += share.BaseAmount
+			category.Count++
+		}
+
+		title := strings.Join(strings.Fields(expense.Title), " ")
+		merchantKey := strings.ToLower(title)
+		if expense.MerchantID != nil && merchantNames[*expense.MerchantID] != "" {
+			title = merchantNames[*expense.MerchantID]
+			merchantKey = fmt.Sprintf("#%d", *expense.MerchantID)
+		}
+		if title == "" {
+			continue
+		}
+		merchant, ok := merchants[merchantKey]
+		if !ok {
+			merchant = &models.MerchantTotal{Merchant: title}
+			merchants[merchantKey] = merchant
+		}
+		merchant.Total += expense.BaseAmount
+		merchant.Count++
+	}
+	report.Net = report.Income - report.Expense
+	report.SavingsRate = report.Net.Percent(report.Income)
+
+	report.Categories = make([]models.CategoryTotal, 0, len(categories))
+	for _, category := range categories {
+		category.Share = category.Total.Percent(report.Expense)
+		report.Categories = append(report.Categories, *category)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		if report.Categories[i].Total != report.Categories[j].Total {
+			return report.Categories[i].Total > report.Categories[j].Total
+		}
+		return report.Categories[i].Category < report.Categories[j].Category
+	})
+
+	report.Merchants = make([]models.MerchantTotal, 0, len(merchants))
+	for _, merchant := range merchants {
+		report.Merchants = append(report.Merchants, *merchant)
+	}
+	sort.Slice(report.Merchants, func(i, j int) bool {
+		if report.Merchants[i].Total != report.Merchants[j].Total {
+			return report.Merchants[i].Total > report.Merchants[j].Total
+		}
+		return report.Merchants[i].Merchant < report.Merchants[j].Merchant
+	})
+	if len(report.Merchants) > reportTopMerchants {
+		report.Merchants = report.Merchants[:reportTopMerchants]
+	}
+
+	// Budgets are evaluated exactly as GetBudgets does, over their own
+	// period, for every active budget that overlaps the month
+	var budgets []models.Budget
+	config.DB.Preload("Tag").
+		Where("is_active = ? AND user_id = ? AND start_date < ? AND end_date >= ?", true, userID, end, month).
+		Order("start_date, id").Find(&budgets)
+	report.Budgets = make([]models.BudgetWithSpending, 0, len(budgets))
+	for _, budget := range budgets {
+		report.Budgets = append(report.Budgets, buildBudgetWithSpending(userID, budget))
+	}
+
+	return report, nil
+}
+
+// GetMonthlyReport returns the statement for a YYYY-MM month as a PDF
+// download, or as JSON with ?format=json
+func GetMonthlyReport(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	month, err := time.ParseInLocation("2006-01", c.Param("month"), time.Now().Location())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month, expected YYYY-MM"})
+		return
+	}
+	if month.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Month must not be in the future"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format", "supported": []string{"pdf", "json"}})
+		return
+	}
+
+	report, err := buildMonthlyReport(userID, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := utils.WriteMonthlyReportPDF(&buf, report); err != nil {
+		log.Printf("❌ Failed to render monthly report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render report"})
+		return
+	}
+
+	filename := fmt.Sprintf("capify-statement-%s.pdf", report.Month)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// loadExpenseSnapshot reloads an expense with its tags and items and
+// captures its current state
+func loadExpenseSnapshot(db *gorm.DB, expense *models.Expense) (models.ExpenseSnapshot, error) {
+	if err := preloadExpenseDetails(db).First(expense, expense.ID).Error; err != nil {
+		return models.ExpenseSnapshot{}, err
+	}
+	return utils.ExpenseSnapshotOf(*expense), nil
+}
+
+// recordExpenseRevision stores the state an edit left an expense in, along
+// with the fields it changed. The state before the first recorded edit is
+// stored as revision 1 so it can be reverted to. Edits that changed nothing
+// are not recorded and return nil.
+func recordExpenseRevision(db *gorm.DB, expense models.Expense, before, after models.ExpenseSnapshot, action string, revertedTo *int) (*models.ExpenseRevision, error) {
+	changes := utils.DiffExpenseSnapshots(before, after)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	var revision *models.ExpenseRevision
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.ExpenseRevision{}).Where("expense_id = ?", expense.ID).
+			Select("COALESCE(MAX(number), 0)").Row().Scan(&latest); err != nil {
+			return err
+		}
+		if latest == 0 {
+			original := models.ExpenseRevision{
+				ExpenseID: expense.ID,
+				UserID:    expense.UserID,
+				Number:    1,
+				Action:    models.RevisionOriginal,
+				Changes:   []models.FieldChange{},
+				Snapshot:  before,
+				CreatedAt: expense.CreatedAt,
+			}
+			if err := tx.Create(&original).Error; err != nil {
+				return err
+			}
+			latest = 1
+		}
+
+		revision = &models.ExpenseRevision{
+			ExpenseID:  expense.ID,
+			UserID:     expense.UserID,
+			Number:     latest + 1,
+			Action:     action,
+			RevertedTo: revertedTo,
+			Changes:    changes,
+			Snapshot:   after,
+		}
+		return tx.Create(revision).Error
+	})
+	return revision, err
+}
+
+// GetExpenseRevisions lists the recorded edits of an expense, newest first
+func GetExpenseRevisions(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var expense models.Expense
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&expense).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found or unauthorized"})
+		return
+	}
+
+	var revisions []models.ExpenseRevision
+	config.DB.Where("expense_id = ?", expense.ID).Order("number DESC").Find(&revisions)
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// RevertExpense restores an expense to the state recorded in one of its
+// revisions. The revert is recorded as a new revision, so it can be undone
+// too. Amounts are re-converted at today's stored rate for the spend date.
+func RevertExpense(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+		return
+	}
+
+	expense, before, ok := findExpenseForEdit(c, userID)
+	if !ok {
+		return
+	}
+
+	var revision models.ExpenseRevision
+	if err := config.DB.Where("expense_id = ? AND number = ?", expense.ID, number).First(&revision).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+
+	// Rebuild the expense from the snapshot. Merged or deleted merchants are
+	// simply unlinked; a category or account that is gone blocks the revert.
+	target := revision.Snapshot
+	input := models.Expense{
+		Title:        target.Title,
+		Amount:       target.Amount,
+		Currency:     target.Currency,
+		CategoryID:   target.CategoryID,
+		Category:     target.Category,
+		Description:  target.Description,
+		SpentAt:      target.SpentAt,
+		AccountID:    target.AccountID,
+		MerchantID:   target.MerchantID,
+		Latitude:     target.Latitude,
+		Longitude:    target.Longitude,
+		PlaceName:    target.PlaceName,
+		TagNames:     target.Tags,
+		Reimbursable: target.Reimbursable,
+		TaxSection:   target.TaxSection,
+	}
+	if input.MerchantID != nil {
+		if err := config.DB.Where("id = ? AND user_id = ?", *input.MerchantID, userID).First(&models.Merchant{}).Error; err != nil {
+			input.MerchantID = nil
+		}
+	}
+	for _, item := range target.Items {
+		input.Items = append(input.Items, models.ExpenseItem{
+			Title: item.Title, Amount: item.Amount, CategoryID: item.CategoryID, Category: item.Category,
+		})
+	}
+
+	recorded, status, err := replaceExpense(userID, &expense, before, input, models.RevisionRevert, &revision.Number)
+	if err != nil {
+		if status == http.StatusPreconditionFailed {
+			respondExpenseConflict(c, expense.ID)
+			return
+		}
+		if status == http.StatusBadRequest {
+			status, err = http.StatusConflict, fmt.Errorf("Cannot revert: %w", err)
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if recorded == nil {
+		c.Header("ETag", utils.VersionETag(expense.Version))
+		c.JSON(http.StatusOK, gin.H{"message": "Expense already matches this revision", "expense": expense})
+		return
+	}
+
+	c.Header("ETag", utils.VersionETag(expense.Version))
+	c.JSON(http.StatusOK, gin.H{"message": "Expense reverted successfully", "expense": expense, "revision": recorded})
+}
@@ -0,0 +1,466 @@
+package controllers
+
+import (
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ruleTestSampleSize caps how many matching expenses a rule test returns
+const ruleTestSampleSize = 50
+
+// prepareRule validates a user rule and links its category, account and tags
+func prepareRule(db *gorm.DB, userID uint, rule *models.CategoryRule) error {
+	if err := utils.ValidateRule(*rule); err != nil {
+		return err
+	}
+
+	if rule.CategoryID != nil || rule.Category != "" {
+		category, err := resolveCategory(db, userID, models.CategoryKindExpense, rule.CategoryID, rule.Category)
+		if err != nil {
+			return err
+		}
+		rule.CategoryID = &category.ID
+		rule.Category = category.Name
+	}
+
+	if rule.AccountID != nil {
+		if _, err := findActiveAccount(db, userID, *rule.AccountID); err != nil {
+			return err
+		}
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+	for _, raw := range rule.AddTags {
+		if name := models.NormalizeTagName(raw); name != "" && !seen[name] {
+			seen[name] = true
+			tags = append(tags, name)
+		}
+	}
+	rule.AddTags = tags
+	return nil
+}
+
+// applyCategoryRules runs the user's rules and then the global ones against a
+// new expense. The first matching rule with a usable category sets it, unless
+// the client already chose one; every matching rule adds its tags. The expense
+// must already be converted to the base currency. Returns the applied rule IDs.
+func applyCategoryRules(db *gorm.DB, userID uint, expense *models.Expense) ([]uint, error) {
+	if err := utils.EnsureDefaultCategories(db, userID); err != nil {
+		return nil, err
+	}
+	rules, err := utils.LoadRules(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return applyRules(db, userID, rules, expense), nil
+}
+
+// applyRules is applyCategoryRules with the rules already loaded, so imports
+// load them once rather than once per row
+func applyRules(db *gorm.DB, userID uint, rules []models.CategoryRule, expense *models.Expense) []uint {
+	var applied []uint
+	categorySet := expense.CategoryID != nil
+	for _, rule := range rules {
+		if !utils.RuleMatches(rule, *expense) {
+			continue
+		}
+
+		used := false
+		if !categorySet && (rule.CategoryID != nil || rule.Category != "") {
+			// A global rule naming a category the user removed just doesn't apply
+			category, err := findCategory(db, userID, models.CategoryKindExpense, rule.CategoryID, rule.Category)
+			if err == nil && category != nil {
+				expense.CategoryID = &category.ID
+				expense.Category = category.Name
+				categorySet = true
+				used = true
+			}
+		}
+		if len(rule.AddTags) > 0 {
+			expense.TagNames = append(expense.TagNames, rule.AddTags...)
+			used = true
+		}
+		if used {
+			applied = append(applied, rule.ID)
+		}
+	}
+
+	if len(applied) > 0 {
+		db.Model(&models.CategoryRule{}).Where("id IN ?", applied).Updates(map[string]interface{}{
+			"match_count":     gorm.Expr("match_count + 1"),
+			"last_matched_at": time.Now(),
+		})
+	}
+	return applied
+}
+
+// recordCategoryCorrection remembers that the user moved an expense to
+// another category so repeated corrections can become rule suggestions
+func recordCategoryCorrection(db *gorm.DB, expense models.Expense, title string, toCategoryID uint) error {
+	if expense.CategoryID != nil && *expense.CategoryID == toCategoryID {
+		return nil
+	}
+	key := utils.MerchantKey(title)
+	if key == "" {
+		return nil
+	}
+	return db.Create(&models.CategoryCorrection{
+		UserID:         expense.UserID,
+		ExpenseID:      expense.ID,
+		MerchantKey:    key,
+		FromCategoryID: expense.CategoryID,
+		ToCategoryID:   toCategoryID,
+	}).Error
+}
+
+// userCategoryNames indexes the user's expense categories by ID and normalized name
+func userCategoryNames(userID uint) (map[uint]string, map[string]uint) {
+	var categories []models.Category
+	config.DB.Where("user_id = ? AND kind = ?", userID, models.CategoryKindExpense).Find(&categories)
+
+	byID := make(map[uint]string, len(categories))
+	byName := make(map[string]uint, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category.Name
+		byName[category.NormalizedName] = category.ID
+	}
+	return byID, byName
+}
+
+// ruleCategory resolves a rule's category against the user's categories
+// without touching the database
+func ruleCategory(rule models.CategoryRule, byID map[uint]string, byName map[string]uint) (uint, bool) {
+	if rule.CategoryID != nil {
+		_, ok := byID[*rule.CategoryID]
+		return *rule.CategoryID, ok
+	}
+	if rule.Category != "" {
+		id, ok := byName[models.NormalizeCategoryName(rule.Category)]
+		return id, ok
+	}
+	return 0, false
+}
+
+// winningCategoryRule finds the rule that would categorize an expense
+func winningCategoryRule(rules []models.CategoryRule, expense models.Expense, byID map[uint]string, byName map[string]uint) (*models.CategoryRule, uint) {
+	for i, rule := range rules {
+		if !utils.RuleMatches(rule, expense) {
+			continue
+		}
+		if categoryID, ok := ruleCategory(rule, byID, byName); ok {
+			return &rules[i], categoryID
+		}
+	}
+	return nil, 0
+}
+
+// GetRules lists the user's rules followed by the global rules, in evaluation order
+func GetRules(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var rules []models.CategoryRule
+	config.DB.Where("user_id = ? OR user_id IS NULL", userID).Find(&rules)
+	utils.SortRules(rules)
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// CreateRule adds a categorization rule for the user
+func CreateRule(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var rule models.CategoryRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule.ID = 0
+	rule.UserID = &userID
+	rule.IsActive = true
+	rule.MatchCount = 0
+	rule.LastMatchedAt = nil
+	if err := prepareRule(config.DB, userID, &rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := config.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"rule": rule})
+}
+
+// UpdateRule edits one of the user's rules. Fields left out of the body keep
+// their current values; send null to clear an optional condition.
+func UpdateRule(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Global rules never match here, so they can't be edited through the API
+	var rule models.CategoryRule
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+		return
+	}
+
+	// Decode over a separately loaded copy so pointer fields aren't shared with rule
+	var updated models.CategoryRule
+	config.DB.First(&updated, rule.ID)
+	if err := c.ShouldBindJSON(&updated); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated.Model = rule.Model
+	updated.UserID = rule.UserID
+	updated.MatchCount = rule.MatchCount
+	updated.LastMatchedAt = rule.LastMatchedAt
+	if updated.CategoryID != nil && (rule.CategoryID == nil || *updated.CategoryID != *rule.CategoryID) {
+		// A new category_id wins over the stale name
+		updated.Category = ""
+	}
+	if err := prepareRule(config.DB, userID, &updated); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := config.DB.Save(&updated).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rule": updated})
+}
+
+// DeleteRule removes one of the user's rules
+func DeleteRule(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var rule models.CategoryRule
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+		return
+	}
+
+	config.DB.Delete(&rule)
+	c.JSON(http.StatusOK, gin.H{"message": "Rule deleted successfully"})
+}
+
+// TestRule dry-runs a rule against the user's recent expenses. The body is a
+// rule as for POST /rules; pass ?rule_id= to test edits to an existing rule.
+// A match is effective when no higher-priority rule would categorize it first.
+func TestRule(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var draft models.CategoryRule
+	if value := c.Query("rule_id"); value != "" {
+		if err := config.DB.Where("id = ? AND user_id = ?", value, userID).First(&draft).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+			return
+		}
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&draft); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	draft.UserID = &userID
+	draft.IsActive = true
+	if err := prepareRule(config.DB, userID, &draft); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := 500
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 5000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 5000"})
+			return
+		}
+		limit = parsed
+	}
+
+	rules, err := utils.LoadRules(config.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	others := rules[:0]
+	for _, rule := range rules {
+		if draft.ID == 0 || rule.ID != draft.ID {
+			others = append(others, rule)
+		}
+	}
+	rules = append(others, draft)
+	utils.SortRules(rules)
+
+	byID, byName := userCategoryNames(userID)
+	draftCategory, draftHasCategory := ruleCategory(draft, byID, byName)
+
+	var expenses []models.Expense
+	config.DB.Where("user_id = ?", userID).Order("spent_at DESC").Limit(limit).Find(&expenses)
+
+	matched, effective, wouldChange := 0, 0, 0
+	sample := []models.RuleTestMatch{}
+	for _, expense := range expenses {
+		if !utils.RuleMatches(draft, expense) {
+			continue
+		}
+		matched++
+
+		match := models.RuleTestMatch{
+			ExpenseID:        expense.ID,
+			Title:            expense.Title,
+			Amount:           expense.Amount,
+			Currency:         expense.Currency,
+			SpentAt:          expense.SpentAt.Format(time.RFC3339),
+			CurrentCategory:  expense.Category,
+			ProposedCategory: expense.Category,
+			Effective:        true,
+		}
+		if draftHasCategory {
+			winner, _ := winningCategoryRule(rules, expense, byID, byName)
+			match.Effective = winner != nil && winner.ID == draft.ID
+			if match.Effective {
+				match.ProposedCategory = byID[draftCategory]
+				if expense.CategoryID == nil || *expense.CategoryID != draftCategory {
+					wouldChange++
+				}
+			}
+		}
+		if match.Effective {
+			effective++
+		}
+		if len(sample) < ruleTestSampleSize {
+			sample = append(sample, match)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"scanned":      len(expenses),
+		"matched":      matched,
+		"effective":    effective,
+		"would_change": wouldChange,
+		"matches":      sample,
+	})
+}
+
+// GetRuleSuggestions proposes title rules for merchants the user keeps
+// moving to the same category. ?min= sets how many corrections it takes (default 2).
+func GetRuleSuggestions(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	minimum := 2
+	if value := c.Query("min"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min must be a positive number"})
+			return
+		}
+		minimum = parsed
+	}
+
+	type correctionCount struct {
+		MerchantKey  string
+		ToCategoryID uint
+		Count        int
+	}
+	var counts []correctionCount
+	config.DB.Model(&models.CategoryCorrection{}).
+		Select("merchant_key, to_category_id, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Group("merchant_key, to_category_id").
+		Scan(&counts)
+
+	totals := make(map[string]int)
+	best := make(map[string]correctionCount)
+	for _, count := range counts {
+		totals[count.MerchantKey] += count.Count
+		if current, ok := best[count.MerchantKey]; !ok || count.Count > current.Count {
+			best[count.MerchantKey] = count
+		}
+	}
+
+	rules, err := utils.LoadRules(config.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byID, byName := userCategoryNames(userID)
+
+	suggestions := []models.RuleSuggestion{}
+	for key, count := range best {
+		categoryName, ok := byID[count.ToCategoryID]
+		if !ok || count.Count < minimum {
+			continue
+		}
+		confidence := float64(count.Count) / float64(totals[key])
+		if confidence < 0.5 {
+			continue
+		}
+
+		// Skip merchants the current rules already file correctly
+		if _, categoryID := winningCategoryRule(rules, models.Expense{Title: key}, byID, byName); categoryID == count.ToCategoryID {
+			continue
+		}
+
+		categoryID := count.ToCategoryID
+		suggestions = append(suggestions, models.RuleSuggestion{
+			MerchantKey: key,
+			CategoryID:  categoryID,
+			Category:    categoryName,
+			Corrections: count.Count,
+			Confidence:  confidence,
+			Rule: models.CategoryRule{
+				Name:          "Learned: " + key,
+				TitleContains: key,
+				CategoryID:    &categoryID,
+				Category:      categoryName,
+				IsActive:      true,
+			},
+		})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Corrections != suggestions[j].Corrections {
+			return suggestions[i].Corrections > suggestions[j].Corrections
+		}
+		return suggestions[i].MerchantKey < suggestions[j].MerchantKey
+	})
+
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"errors"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSMSBatch caps how many messages one parse request may carry
+const maxSMSBatch = 500
+
+// matchAccountBySuffix finds the user's account whose number ends with the
+// digits quoted in an SMS. Ambiguous suffixes are left unmatched.
+func matchAccountBySuffix(accounts []models.Account, suffix string) *uint {
+	if suffix == "" {
+		return nil
+	}
+	var match *uint
+	for i := range accounts {
+		stored := accounts[i].NumberSuffix
+		if stored == "" || !(strings.HasSuffix(stored, suffix) || strings.HasSuffix(suffix, stored)) {
+			continue
+		}
+		if match != nil {
+			return nil
+		}
+		match = &accounts[i].ID
+	}
+	return match
+}
+
+// ParseSMS turns raw bank/UPI SMS text into draft expenses and income for the
+// user to confirm. Nothing is saved; confirmed drafts are posted to /expenses
+// or /incomes as usual.
+func ParseSMS(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.ParseSMSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	messages := req.Messages
+	if strings.TrimSpace(req.Text) != "" {
+		messages = append([]models.SMSMessage{req.SMSMessage}, messages...)
+	}
+	if len(messages) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Send the SMS in 'text' or a list in 'messages'"})
+		return
+	}
+	if len(messages) > maxSMSBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many messages", "max": maxSMSBatch})
+		return
+	}
+
+	var accounts []models.Account
+	config.DB.Where("user_id = ? AND is_active = ? AND number_suffix <> ''", userID, true).Find(&accounts)
+
+	drafts := []models.SMSDraft{}
+	skipped := []models.SMSSkipped{}
+	for i, message := range messages {
+		draft, err := utils.ParseSMS(message)
+		if err != nil {
+			reason := err.Error()
+			if !errors.Is(err, utils.ErrNotTransaction) {
+				reason = "unrecognized message: " + reason
+			}
+			skipped = append(skipped, models.SMSSkipped{Index: i, Reason: reason})
+			continue
+		}
+		draft.Index = i
+		draft.AccountID = matchAccountBySuffix(accounts, draft.AccountSuffix)
+		// Only known merchants are matched; confirming the draft creates new ones
+		if merchant, err := utils.FindMerchant(config.DB, userID, draft.Merchant, draft.VPA); err == nil && merchant != nil {
+			draft.MerchantID = &merchant.ID
+		}
+		drafts = append(drafts, draft)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"drafts": drafts, "skipped": skipped})
+}
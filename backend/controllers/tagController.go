@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// resolveTags finds the user's tags by name, creating any that don't exist yet
+func resolveTags(db *gorm.DB, userID uint, names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	seen := make(map[string]bool)
+
+	for _, raw := range names {
+		name := models.NormalizeTagName(raw)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var tag models.Tag
+		err := db.Where("user_id = ? AND name = ?", userID, name).First(&tag).Error
+		if err == gorm.ErrRecordNotFound {
+			tag = models.Tag{UserID: userID, Name: name}
+			err = db.Create(&tag).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// splitTagNames parses a comma-separated list of tag names from a query parameter
+func splitTagNames(value string) []string {
+	var names []string
+	for _, part := range strings.Split(value, ",") {
+		if name := models.NormalizeTagName(part); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// applyTagFilters narrows an expense query by tag combinations:
+// tags=a,b requires all, any_tags=a,b requires at least one, exclude_tags=a,b requires none
+func applyTagFilters(c *gin.Context, query *gorm.DB, userID uint) *gorm.DB {
+	if names := splitTagNames(c.Query("tags")); len(names) > 0 {
+		query = query.Where(`expenses.id IN (SELECT et.expense_id FROM expense_tags et JOIN tags t ON t.id = et.tag_id
+			WHERE t.user_id = ? AND t.name IN ? GROUP BY et.expense_id HAVING COUNT(DISTINCT t.id) = ?)`,
+			userID, names, len(names))
+	}
+	if names := splitTagNames(c.Query("any_tags")); len(names) > 0 {
+		query = query.Where(`expenses.id IN (SELECT et.expense_id FROM expense_tags et JOIN tags t ON t.id = et.tag_id
+			WHERE t.user_id = ? AND t.name IN ?)`, userID, names)
+	}
+	if names := splitTagNames(c.Query("exclude_tags")); len(names) > 0 {
+		query = query.Where(`expenses.id NOT IN (SELECT et.expense_id FROM expense_tags et JOIN tags t ON t.id = et.tag_id
+			WHERE t.user_id = ? AND t.name IN ?)`, userID, names)
+	}
+	return query
+}
+
+// GetTags lists the user's tags with how many expenses use each
+func GetTags(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type tagWithUsage struct {
+		models.Tag
+		ExpenseCount int64 `json:"expense_count"`
+	}
+
+	var tags []tagWithUsage
+	config.DB.Model(&models.Tag{}).
+		Select(`tags.*, (SELECT COUNT(*) FROM expense_tags et JOIN expenses e ON e.id = et.expense_id
+			WHERE et.tag_id = tags.id AND e.deleted_at IS NULL) AS expense_count`).
+		Where("user_id = ?", userID).
+		Order("name").
+		Scan(&tags)
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// CreateTag adds a tag ahead of use
+func CreateTag(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := models.NormalizeTagName(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tag name is required"})
+		return
+	}
+
+	var existing models.Tag
+	if config.DB.Where("user_id = ? AND name = ?", userID, name).First(&existing).Error == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tag already exists", "tag": existing})
+		return
+	}
+
+	tag := models.Tag{UserID: userID, Name: name, Color: req.Color}
+	if err := config.DB.Create(&tag).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"tag": tag})
+}
+
+// UpdateTag renames or recolors a tag
+func UpdateTag(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var tag models.Tag
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&tag).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+
+	var req models.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if name := models.NormalizeTagName(req.Name); name != "" && name != tag.Name {
+		var existing models.Tag
+		if config.DB.Where("user_id = ? AND name = ? AND id <> ?", userID, name, tag.ID).First(&existing).Error == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another tag already has this name"})
+			return
+		}
+		tag.Name = name
+	}
+	if req.Color != "" {
+		tag.Color = req.Color
+	}
+
+	config.DB.Save(&tag)
+	c.JSON(http.StatusOK, gin.H{"tag": tag})
+}
+
+// DeleteTag removes a tag from all expenses. Tags backing an active budget can't be deleted.
+func DeleteTag(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var tag models.Tag
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&tag).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+
+	var budgetCount int64
+	config.DB.Model(&models.Budget{}).Where("tag_id = ?", tag.ID).Count(&budgetCount)
+	if budgetCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tag is used by a budget", "budgets": budgetCount})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM expense_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
+
+// GetTagSummary reports spending totals per tag for a date range (defaults to this month)
+func GetTagSummary(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 1, 0)
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	baseCurrency := getUserBaseCurrency(userID)
+
+	var summaries []models.TagSummary
+	config.DB.Raw(`SELECT t.id AS tag_id, t.name, COALESCE(SUM(e.base_amount), 0) AS total, COUNT(e.id) AS count
+		FROM tags t
+		JOIN expense_tags et ON et.tag_id = t.id
+		JOIN expenses e ON e.id = et.expense_id AND e.deleted_at IS NULL
+		WHERE t.user_id = ? AND t.deleted_at IS NULL AND e.base_currency = ? AND e.spent_at >= ? AND e.spent_at < ?
+		GROUP BY t.id, t.name
+		ORDER BY total DESC`, userID, baseCurrency, from, to).Scan(&summaries)
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from.Format("2006-01-02"),
+		"to":       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"currency": baseCurrency,
+		"tags":     summaries,
+	})
+}
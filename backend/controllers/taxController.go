@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// expenseAmountInRupees converts an expense into rupees at its spend date,
+// preferring amounts already in rupees
+func expenseAmountInRupees(expense models.Expense) (models.Money, error) {
+	if expense.BaseCurrency == utils.TaxCurrency {
+		return expense.BaseAmount, nil
+	}
+	if expense.Currency == utils.TaxCurrency {
+		return expense.Amount, nil
+	}
+	rate, err := utils.FindExchangeRate(config.DB, expense.Currency, utils.TaxCurrency, expense.SpentAt)
+	if err != nil {
+		return 0, err
+	}
+	return utils.ConvertMoney(expense.Amount, rate), nil
+}
+
+// buildTaxSummary totals the user's tax-deductible spending for the financial
+// year starting in April of start. Reimbursed expenses are listed apart and
+// not counted. The senior flags raise the 80D limits for insuring senior
+// citizens.
+func buildTaxSummary(userID uint, start int, senior, parentsSenior bool) (models.TaxSummary, error) {
+	from, to := utils.FinancialYearRange(start)
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return models.TaxSummary{}, err
+	}
+
+	summary := models.TaxSummary{
+		FinancialYear: utils.FinancialYearLabel(start),
+		From:          from,
+		To:            to,
+		Currency:      utils.TaxCurrency,
+		UserName:      user.Name,
+		GeneratedAt:   time.Now(),
+		Sections:      make([]models.TaxSectionSummary, len(utils.TaxSections)),
+		Expenses:      []models.TaxSummaryExpense{},
+	}
+	sections := make(map[string]*models.TaxSectionSummary)
+	for i, section := range utils.TaxSections {
+		if (section.Code == "80D" && senior) || (section.Code == "80D-PARENTS" && parentsSenior) {
+			section.Limit = section.SeniorLimit
+		}
+		summary.Sections[i] = models.TaxSectionSummary{TaxSection: section}
+		sections[section.Code] = &summary.Sections[i]
+	}
+
+	var expenses []models.Expense
+	if err := config.DB.Where("user_id = ? AND tax_section <> '' AND spent_at >= ? AND spent_at < ?", userID, from, to).
+		Order("spent_at, id").Find(&expenses).Error; err != nil {
+		return summary, err
+	}
+	for _, expense := range expenses {
+		section, ok := sections[expense.TaxSection]
+		if !ok {
+			continue
+		}
+		// Spending someone else paid back isn't the user's to deduct
+		if expense.ReimbursementStatus == models.ReimbursementReimbursed {
+			summary.Reimbursed = append(summary.Reimbursed, expense.ID)
+			continue
+		}
+		amount, err := expenseAmountInRupees(expense)
+		if err != nil {
+			summary.Unconverted = append(summary.Unconverted, expense.ID)
+			continue
+		}
+		section.Count++
+		section.Total += amount
+		summary.Expenses = append(summary.Expenses, models.TaxSummaryExpense{
+			ExpenseID: expense.ID,
+			Section:   expense.TaxSection,
+			SpentAt:   expense.SpentAt,
+			Title:     expense.Title,
+			Category:  expense.Category,
+			Amount:    amount,
+		})
+	}
+
+	for i := range summary.Sections {
+		section := &summary.Sections[i]
+		section.Deductible = section.Total
+		if section.Limit > 0 {
+			remaining := section.Limit - section.Total
+			if remaining < 0 {
+				remaining = 0
+				section.Deductible = section.Limit
+			}
+			section.Remaining = &remaining
+		}
+		summary.TotalDeductible += section.Deductible
+	}
+	return summary, nil
+}
+
+// GetTaxSections lists the tax sections expenses can be classified under
+func GetTaxSections(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"sections": utils.TaxSections, "currency": utils.TaxCurrency})
+}
+
+// GetTaxSummary returns the section-wise deduction summary of a financial year
+// (?fy=2024-25, default the current one) with the headroom left under each
+// limit. Pass senior_citizen=true or parents_senior=true for the higher 80D
+// limits. JSON by default; ?format=csv or ?format=pdf downloads it.
+func GetTaxSummary(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	start := utils.FinancialYearOf(time.Now())
+	if value := c.Query("fy"); value != "" {
+		if start, err = utils.ParseFinancialYear(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format", "supported": []string{"json", "csv", "pdf"}})
+		return
+	}
+
+	summary, err := buildTaxSummary(userID, start, c.Query("senior_citizen") == "true", c.Query("parents_senior") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, summary)
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "application/pdf"
+	if format == "csv" {
+		contentType = exportContentTypes["csv"]
+		writer := csv.NewWriter(&buf)
+		writer.Write(utils.TaxSummaryCSVHeader)
+		for _, section := range summary.Sections {
+			writer.Write(utils.TaxSummaryCSVRecord(summary, section))
+		}
+		writer.Flush()
+		err = writer.Error()
+	} else {
+		err = utils.WriteTaxSummaryPDF(&buf, summary)
+	}
+	if err != nil {
+		log.Printf("❌ Failed to render tax summary: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render report"})
+		return
+	}
+
+	filename := fmt.Sprintf("capify-tax-summary-FY%s.%s", summary.FinancialYear, format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
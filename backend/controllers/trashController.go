@@ -0,0 +1,320 @@
+package controllers
+
+import (
+	"context"
+	"finance-app-backend/config"
+	"finance-app-backend/models"
+	"finance-app-backend/utils"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// purgeBatchSize bounds how many expenses are purged per statement
+const purgeBatchSize = 500
+
+// trashRetention reads how long deleted expenses and budgets stay restorable
+// from TRASH_RETENTION_DAYS, defaulting to 30 days
+func trashRetention() time.Duration {
+	if value := os.Getenv("TRASH_RETENTION_DAYS"); value != "" {
+		if days, err := strconv.Atoi(value); err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour
+		}
+		log.Printf("⚠️ Invalid TRASH_RETENTION_DAYS %q, using 30", value)
+	}
+	return 30 * 24 * time.Hour
+}
+
+// budgetTitle names a budget by its category, or its tag for tag budgets
+func budgetTitle(budget models.Budget) string {
+	if budget.Tag != nil {
+		return "#" + budget.Tag.Name
+	}
+	return budget.Category
+}
+
+// GetTrash lists the user's deleted expenses and budgets, most recently
+// deleted first. Pass ?type=expense or ?type=budget to list only one kind.
+func GetTrash(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	kind := c.Query("type")
+	if kind != "" && kind != models.TrashTypeExpense && kind != models.TrashTypeBudget {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be expense or budget"})
+		return
+	}
+
+	retention := trashRetention()
+	entries := []models.TrashEntry{}
+
+	if kind == "" || kind == models.TrashTypeExpense {
+		var expenses []models.Expense
+		if err := preloadExpenseDetails(config.DB.Unscoped()).
+			Where("user_id = ? AND deleted_at IS NOT NULL", userID).Find(&expenses).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for i := range expenses {
+			expense := &expenses[i]
+			entries = append(entries, models.TrashEntry{
+				Type:      models.TrashTypeExpense,
+				ID:        expense.ID,
+				Title:     expense.Title,
+				Amount:    expense.Amount,
+				Currency:  expense.Currency,
+				Date:      expense.SpentAt,
+				DeletedAt: expense.DeletedAt.Time,
+				PurgeAt:   expense.DeletedAt.Time.Add(retention),
+				Expense:   expense,
+			})
+		}
+	}
+
+	if kind == "" || kind == models.TrashTypeBudget {
+		var budgets []models.Budget
+		if err := config.DB.Unscoped().Preload("Tag").
+			Where("user_id = ? AND deleted_at IS NOT NULL", userID).Find(&budgets).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for i := range budgets {
+			budget := &budgets[i]
+			entries = append(entries, models.TrashEntry{
+				Type:      models.TrashTypeBudget,
+				ID:        budget.ID,
+				Title:     budgetTitle(*budget),
+				Amount:    budget.Amount,
+				Currency:  budget.Currency,
+				Date:      budget.StartDate,
+				DeletedAt: budget.DeletedAt.Time,
+				PurgeAt:   budget.DeletedAt.Time.Add(retention),
+				Budget:    budget,
+			})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":          entries,
+		"count":          len(entries),
+		"retention_days": int(retention.Hours() / 24),
+	})
+}
+
+// RestoreExpense brings a deleted expense back from the trash, re-converting
+// it when the base currency changed while it was deleted
+func RestoreExpense(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var expense models.Expense
+	if err := config.DB.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", c.Param("id"), userID).
+		First(&expense).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found in trash"})
+		return
+	}
+
+	// The base currency may have changed while the expense was in the trash
+	updates := map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}
+	baseCurrency := getUserBaseCurrency(userID)
+	reconvert := expense.BaseCurrency != baseCurrency
+	if reconvert {
+		if err := utils.ConvertExpenseToBase(config.DB, &expense, baseCurrency); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["base_currency"] = expense.BaseCurrency
+		updates["exchange_rate"] = expense.ExchangeRate
+		updates["base_amount"] = expense.BaseAmount
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Expense{}).Where("id = ?", expense.ID).UpdateColumns(updates).Error; err != nil {
+			return err
+		}
+		if reconvert {
+			return utils.ReallocateItemBaseAmounts(tx, expense.ID, expense.BaseAmount)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	preloadExpenseDetails(config.DB).First(&expense, expense.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Expense restored successfully", "expense": expense})
+}
+
+// RestoreBudget brings a deleted budget back from the trash. It fails when
+// its category or tag is gone, or when an active budget now covers the same
+// scope and dates.
+func RestoreBudget(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var budget models.Budget
+	if err := config.DB.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", c.Param("id"), userID).
+		First(&budget).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget not found in trash"})
+		return
+	}
+
+	if budget.TagID != nil {
+		if err := config.DB.Where("id = ? AND user_id = ?", *budget.TagID, userID).First(&models.Tag{}).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "The budget's tag no longer exists"})
+			return
+		}
+	} else if budget.CategoryID != nil {
+		if err := config.DB.Where("id = ? AND user_id = ?", *budget.CategoryID, userID).First(&models.Category{}).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "The budget's category no longer exists"})
+			return
+		}
+	}
+
+	if budget.IsActive && budgetOverlaps(userID, budget) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Another active budget already covers this category and period"})
+		return
+	}
+
+	// Budgets are kept in the base currency, which may have changed meanwhile
+	updates := map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}
+	if baseCurrency := getUserBaseCurrency(userID); budget.Currency != baseCurrency {
+		rate, err := utils.FindExchangeRate(config.DB, budget.Currency, baseCurrency, time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["amount"] = utils.ConvertMoney(budget.Amount, rate)
+		updates["currency"] = baseCurrency
+	}
+
+	if err := config.DB.Unscoped().Model(&models.Budget{}).Where("id = ?", budget.ID).UpdateColumns(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	config.DB.Preload("Tag").First(&budget, budget.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Budget restored successfully", "budget": buildBudgetWithSpending(userID, budget)})
+}
+
+// EmptyTrash permanently deletes everything in the user's trash
+func EmptyTrash(c *gin.Context) {
+	userID, err := getUserIDFromToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	expenses, budgets, err := purgeTrash(func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ?", userID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Trash emptied successfully",
+		"expenses_deleted": expenses,
+		"budgets_deleted":  budgets,
+	})
+}
+
+// PurgeTrash permanently deletes expenses and budgets that have been in the
+// trash longer than the retention window. It is run by the background jobs.
+func PurgeTrash(now time.Time) (int64, int64, error) {
+	cutoff := now.Add(-trashRetention())
+	return purgeTrash(func(db *gorm.DB) *gorm.DB {
+		return db.Where("deleted_at < ?", cutoff)
+	})
+}
+
+// purgeTrash permanently deletes the trashed expenses and budgets matching
+// scope, along with the expenses' line items, revisions, bulk edit entries, tag
+// links and receipt files
+func purgeTrash(scope func(*gorm.DB) *gorm.DB) (int64, int64, error) {
+	var expensesDeleted int64
+	for {
+		var expenseIDs []uint
+		if err := config.DB.Unscoped().Model(&models.Expense{}).Scopes(scope).
+			Where("deleted_at IS NOT NULL").Limit(purgeBatchSize).Pluck("id", &expenseIDs).Error; err != nil {
+			return expensesDeleted, 0, err
+		}
+		if len(expenseIDs) == 0 {
+			break
+		}
+		if err := purgeExpenses(expenseIDs); err != nil {
+			return expensesDeleted, 0, err
+		}
+		expensesDeleted += int64(len(expenseIDs))
+	}
+
+	result := config.DB.Unscoped().Scopes(scope).Where("deleted_at IS NOT NULL").Delete(&models.Budget{})
+	return expensesDeleted, result.RowsAffected, result.Error
+}
+
+// purgeExpenses permanently deletes expenses and everything attached to them.
+// Receipt files are removed once the rows are gone; a file that fails to
+// delete is only logged since nothing references it any more.
+func purgeExpenses(expenseIDs []uint) error {
+	var receipts []models.Receipt
+	if err := config.DB.Unscoped().Where("expense_id IN ?", expenseIDs).Find(&receipts).Error; err != nil {
+		return err
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("expense_id IN ?", expenseIDs).Delete(&models.Receipt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("expense_id IN ?", expenseIDs).Delete(&models.ExpenseItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("expense_id IN ?", expenseIDs).Delete(&models.ExpenseRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("expense_id IN ?", expenseIDs).Delete(&models.BulkOperationEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM expense_tags WHERE expense_id IN ?", expenseIDs).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", expenseIDs).Delete(&models.Expense{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	store := utils.GetBlobStore()
+	ctx := context.Background()
+	for _, receipt := range receipts {
+		for _, key := range []string{receipt.StorageKey, receipt.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := store.Delete(ctx, key); err != nil {
+				log.Printf("⚠️ Failed to delete receipt file %s: %v", key, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"finance-app-backend/controllers"
+	"log"
+	"os"
+	"time"
+)
+
+// interval reads how often background jobs run from JOBS_INTERVAL (e.g. "15m"), defaulting to an hour
+func interval() time.Duration {
+	if value := os.Getenv("JOBS_INTERVAL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			return parsed
+		}
+		log.Printf("⚠️ Invalid JOBS_INTERVAL %q, using 1h", value)
+	}
+	return time.Hour
+}
+
+// Start runs the periodic background jobs in a goroutine. Call it once after
+// the database is connected.
+func Start() {
+	go func() {
+		runAll()
+		ticker := time.NewTicker(interval())
+		defer ticker.Stop()
+		for range ticker.C {
+			runAll()
+		}
+	}()
+}
+
+// runAll executes every job once
+func runAll() {
+	now := time.Now()
+
+	if created, err := controllers.MaterializeRecurringExpenses(now); err != nil {
+		log.Printf("❌ Recurring expense job failed: %v", err)
+	} else if created > 0 {
+		log.Printf("✅ Generated %d recurring expenses", created)
+	}
+
+	if created, err := controllers.MaterializeRecurringIncome(now); err != nil {
+		log.Printf("❌ Recurring income job failed: %v", err)
+	} else if created > 0 {
+		log.Printf("✅ Generated %d recurring income entries", created)
+	}
+
+	if expenses, budgets, err := controllers.PurgeTrash(now); err != nil {
+		log.Printf("❌ Trash purge job failed: %v", err)
+	} else if expenses > 0 || budgets > 0 {
+		log.Printf("✅ Purged %d expenses and %d budgets from trash", expenses, budgets)
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"finance-app-backend/config"
+	"finance-app-backend/jobs"
+	"finance-app-backend/routes"
 	"fmt"
 	"os"
 	"time"
@@ -9,13 +12,49 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// databaseConfigured reports whether a PostgreSQL database is set up. Without
+// one the server runs in simple mode with mock auth endpoints only.
+func databaseConfigured() bool {
+	if os.Getenv("DB_MOCK") == "true" {
+		return false
+	}
+	return os.Getenv("DATABASE_URL") != "" || os.Getenv("PGHOST") != ""
+}
+
+// registerRoutes mounts every API route group
+func registerRoutes(r *gin.Engine) {
+	routes.RegisterAuthRoutes(r)
+	routes.RegisterExpenseRoutes(r)
+	routes.RegisterBudgetRoutes(r)
+	routes.RegisterCategoryRoutes(r)
+	routes.RegisterCurrencyRoutes(r)
+	routes.RegisterTagRoutes(r)
+	routes.RegisterAccountRoutes(r)
+	routes.RegisterIncomeRoutes(r)
+	routes.RegisterReceiptRoutes(r)
+	routes.RegisterSMSRoutes(r)
+	routes.RegisterImportRoutes(r)
+	routes.RegisterExportRoutes(r)
+	routes.RegisterReportRoutes(r)
+	routes.RegisterRuleRoutes(r)
+	routes.RegisterMerchantRoutes(r)
+	routes.RegisterTrashRoutes(r)
+	routes.RegisterClaimRoutes(r)
+	routes.RegisterTaxRoutes(r)
+}
+
 func main() {
 	// Set Gin to release mode for production
 	if os.Getenv("GIN_MODE") == "" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	fmt.Println("🔧 Starting CapiFy Backend (Simple Mode)...")
+	withDatabase := databaseConfigured()
+	if withDatabase {
+		fmt.Println("🔧 Starting CapiFy Backend...")
+	} else {
+		fmt.Println("🔧 Starting CapiFy Backend (Simple Mode)...")
+	}
 	fmt.Printf("Environment: PORT=%s, GIN_MODE=%s\n", os.Getenv("PORT"), gin.Mode())
 
 	r := gin.Default()
@@ -23,8 +62,9 @@ func main() {
 	// Configure CORS
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"*"},
+		ExposeHeaders:    []string{"ETag"},
 		AllowCredentials: true,
 	}))
 
@@ -40,16 +80,53 @@ func main() {
 	})
 
 	// Health check endpoint
+	databaseStatus := "not_connected_simple_mode"
+	if withDatabase {
+		databaseStatus = "connected"
+	}
 	r.GET("/health", func(c *gin.Context) {
 		c.Header("Content-Type", "application/json")
 		c.JSON(200, gin.H{
 			"status":   "healthy",
-			"database": "not_connected_simple_mode",
+			"database": databaseStatus,
 			"time":     fmt.Sprintf("%d", time.Now().Unix()),
 		})
 	})
 
-	// Mock auth endpoints for testing
+	if withDatabase {
+		config.ConnectDatabase()
+		registerRoutes(r)
+
+		// Recurring transactions and the trash purge run in the background
+		jobs.Start()
+	} else {
+		registerMockAuthRoutes(r)
+	}
+
+	// Get port from Railway environment variable or default to 8000
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8000"
+	}
+
+	fmt.Printf("🚀 CapiFy Backend Server starting on :%s\n", port)
+	if !withDatabase {
+		fmt.Println("📱 Available endpoints:")
+		fmt.Println("   GET  / (health)")
+		fmt.Println("   GET  /health")
+		fmt.Println("   POST /auth/send-otp (mock)")
+		fmt.Println("   POST /auth/verify-otp (mock)")
+		fmt.Println("   POST /auth/login (mock)")
+	}
+
+	if err := r.Run("0.0.0.0:" + port); err != nil {
+		fmt.Printf("❌ Failed to start server: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// registerMockAuthRoutes adds canned auth endpoints for running without a database
+func registerMockAuthRoutes(r *gin.Engine) {
 	r.POST("/auth/send-otp", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"success": true,
@@ -85,23 +162,4 @@ func main() {
 			},
 		})
 	})
-
-	// Get port from Railway environment variable or default to 8000
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8000"
-	}
-
-	fmt.Printf("🚀 CapiFy Backend Server starting on :%s\n", port)
-	fmt.Println("📱 Available endpoints:")
-	fmt.Println("   GET  / (health)")
-	fmt.Println("   GET  /health")
-	fmt.Println("   POST /auth/send-otp (mock)")
-	fmt.Println("   POST /auth/verify-otp (mock)")
-	fmt.Println("   POST /auth/login (mock)")
-
-	if err := r.Run("0.0.0.0:" + port); err != nil {
-		fmt.Printf("❌ Failed to start server: %v\n", err)
-		os.Exit(1)
-	}
 }
@@ -3,6 +3,7 @@ package middleware
 import (
 	"finance-app-backend/utils"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -68,3 +69,25 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AdminMiddleware restricts a route to mobile numbers listed in ADMIN_MOBILE_NUMBERS.
+// It must run after AuthMiddleware.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mobile := c.GetString("mobile_number")
+
+		for _, admin := range strings.Split(os.Getenv("ADMIN_MOBILE_NUMBERS"), ",") {
+			admin = strings.TrimSpace(admin)
+			if admin != "" && utils.NormalizeMobileNumber(admin) == mobile {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "Admin access required",
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Account types money can be paid from
+const (
+	AccountTypeCash       = "cash"
+	AccountTypeBank       = "bank"
+	AccountTypeCreditCard = "credit_card"
+	AccountTypeUPIWallet  = "upi_wallet"
+)
+
+// ValidAccountTypes lists the supported account types
+var ValidAccountTypes = []string{AccountTypeCash, AccountTypeBank, AccountTypeCreditCard, AccountTypeUPIWallet}
+
+// Account is a place money is held or spent from (cash wallet, bank, card, UPI wallet).
+// Credit card balances go negative as spending accumulates.
+type Account struct {
+	gorm.Model
+	UserID         uint      `json:"user_id" gorm:"not null;index"`
+	Name           string    `json:"name" gorm:"not null"`
+	Type           string    `json:"type" gorm:"not null;default:'cash'"` // cash, bank, credit_card, upi_wallet
+	Currency       string    `json:"currency" gorm:"size:3;not null;default:'INR'"`
+	OpeningBalance Money     `json:"opening_balance" gorm:"type:bigint;not null;default:0"`
+	OpeningDate    time.Time `json:"opening_date"`
+	NumberSuffix   string    `json:"number_suffix" gorm:"size:6"` // Last digits of the account or card number, matched against bank SMS
+	IsActive       bool      `json:"is_active" gorm:"default:true"`
+}
+
+// AccountWithBalance includes the account's current balance
+type AccountWithBalance struct {
+	Account
+	Balance Money `json:"balance"`
+}
+
+// UpdateAccountRequest is the payload for editing an account. Fields are
+// pointers so that only the ones sent change; a rename must not reset the
+// opening balance.
+type UpdateAccountRequest struct {
+	Name           *string    `json:"name"`
+	Type           *string    `json:"type"`
+	NumberSuffix   *string    `json:"number_suffix"` // Empty clears it
+	OpeningBalance *Money     `json:"opening_balance"`
+	OpeningDate    *time.Time `json:"opening_date"`
+}
+
+// Transfer moves money between two of a user's accounts. Transfers never count as spending.
+type Transfer struct {
+	gorm.Model
+	UserID        uint      `json:"user_id" gorm:"not null;index"`
+	FromAccountID uint      `json:"from_account_id" gorm:"not null;index"`
+	ToAccountID   uint      `json:"to_account_id" gorm:"not null;index"`
+	Amount        Money     `json:"amount" gorm:"type:bigint;not null"`    // In the source account's currency
+	ToAmount      Money     `json:"to_amount" gorm:"type:bigint;not null"` // In the destination account's currency
+	TransferredAt time.Time `json:"transferred_at" gorm:"not null;index"`
+	Note          string    `json:"note"`
+}
+
+// LedgerEntry is one line of an account statement with the running balance after it
+type LedgerEntry struct {
+	Date        time.Time `json:"date"`
+	Type        string    `json:"type"` // opening, expense, income, transfer_in, transfer_out
+	ReferenceID uint      `json:"reference_id"`
+	Description string    `json:"description"`
+	Amount      Money     `json:"amount"` // Signed: negative for money leaving the account
+	Balance     Money     `json:"balance"`
+}
@@ -6,26 +6,48 @@ import (
 	"gorm.io/gorm"
 )
 
-// Budget represents a spending limit for a category
+// Budget represents a spending limit for a category, or for a tag when TagID is set
 type Budget struct {
 	gorm.Model
-	UserID    uint      `json:"user_id" gorm:"not null;index"`
-	Category  string    `json:"category" gorm:"not null"`
-	Amount    float64   `json:"amount" gorm:"not null"`
-	Period    string    `json:"period" gorm:"default:'monthly'"` // monthly, weekly, custom
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	IsActive  bool      `json:"is_active" gorm:"default:true"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	CategoryID *uint     `json:"category_id" gorm:"index"` // Spending in subcategories counts too
+	Category   string    `json:"category" gorm:"not null"`
+	TagID      *uint     `json:"tag_id" gorm:"index"` // Scopes the budget to a tag instead of a category
+	Amount     Money     `json:"amount" gorm:"type:bigint;not null"`
+	Currency   string    `json:"currency" gorm:"size:3;not null;default:'INR'"`
+	Period     string    `json:"period" gorm:"default:'monthly'"` // monthly, weekly, custom
+	StartDate  time.Time `json:"start_date"`
+	EndDate    time.Time `json:"end_date"`
+	IsActive   bool      `json:"is_active" gorm:"default:true"`
+	Version    uint      `json:"version" gorm:"not null;default:1"` // Bumped on every edit, sent as the ETag
 
 	// Relationships
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Tag  *Tag `json:"tag,omitempty" gorm:"foreignKey:TagID"`
+
+	// Tag name to scope the budget to on create/update
+	TagName string `json:"tag_name,omitempty" gorm:"-"`
+}
+
+// PeriodEnd returns the exclusive upper bound of the budget period: the start
+// of the day after EndDate, so spending late on the last day still counts
+func (b Budget) PeriodEnd() time.Time {
+	year, month, day := b.EndDate.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, b.EndDate.Location())
 }
 
 // BudgetWithSpending includes current spending information
 type BudgetWithSpending struct {
 	Budget
-	CurrentSpent float64 `json:"current_spent"`
-	Remaining    float64 `json:"remaining"`
+	CurrentSpent Money   `json:"current_spent"`
+	Remaining    Money   `json:"remaining"`
 	Percentage   float64 `json:"percentage"`
 	Status       string  `json:"status"` // safe, warning, danger
 }
+
+// BudgetRequest is the payload for replacing a budget. IsActive is a pointer
+// so that leaving it out keeps the budget active rather than switching it off.
+type BudgetRequest struct {
+	Budget
+	IsActive *bool `json:"is_active"`
+}
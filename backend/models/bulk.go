@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// BulkExpenseChanges are the field changes of a bulk edit; fields left out
+// stay as they are. Category is looked up by name when CategoryID is not set,
+// and an empty TaxSection clears the expenses' tax section.
+type BulkExpenseChanges struct {
+	CategoryID *uint    `json:"category_id,omitempty"`
+	Category   string   `json:"category,omitempty"`
+	AccountID  *uint    `json:"account_id,omitempty"`
+	MerchantID *uint    `json:"merchant_id,omitempty"`
+	AddTags    []string `json:"add_tags,omitempty"`
+	RemoveTags []string `json:"remove_tags,omitempty"`
+	TaxSection *string  `json:"tax_section,omitempty"`
+}
+
+// BulkUpdateRequest is the body of a bulk edit. The expenses to change are
+// picked with the same query-string filters as GET /expenses.
+type BulkUpdateRequest struct {
+	Changes BulkExpenseChanges `json:"changes"`
+	DryRun  bool               `json:"dry_run"` // Only count and preview what would change
+}
+
+// BulkOperation records a bulk edit of expenses so it can be undone
+type BulkOperation struct {
+	ID        uint               `json:"id" gorm:"primaryKey"`
+	UserID    uint               `json:"user_id" gorm:"not null;index"`
+	Filter    string             `json:"filter"` // Query string that picked the expenses
+	Changes   BulkExpenseChanges `json:"changes" gorm:"serializer:json;type:text"`
+	Matched   int                `json:"matched"`
+	Changed   int                `json:"changed"`
+	UndoneAt  *time.Time         `json:"undone_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// BulkOperationEntry is one expense a bulk edit changed, with its state
+// before the edit and the version the edit left it at
+type BulkOperationEntry struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	OperationID uint            `json:"operation_id" gorm:"not null;index"`
+	ExpenseID   uint            `json:"expense_id" gorm:"not null;index"`
+	Version     uint            `json:"version"` // Undo skips the expense once it has been edited again
+	Before      ExpenseSnapshot `json:"before" gorm:"serializer:json;type:text"`
+}
+
+// BulkChangePreview shows what a bulk edit would change on one expense
+type BulkChangePreview struct {
+	ExpenseID uint          `json:"expense_id"`
+	Title     string        `json:"title"`
+	SpentAt   time.Time     `json:"spent_at"`
+	Changes   []FieldChange `json:"changes"`
+}
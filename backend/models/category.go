@@ -0,0 +1,83 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Category groups expenses and budgets. Every user owns their own copy of the
+// default categories so they can rename, recolor or merge them freely.
+type Category struct {
+	gorm.Model
+	UserID         uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_category_user_name,where:deleted_at IS NULL"`
+	Name           string `json:"name" gorm:"not null"`
+	NormalizedName string `json:"-" gorm:"not null;uniqueIndex:idx_category_user_name,where:deleted_at IS NULL"`
+	Icon           string `json:"icon"`
+	Color          string `json:"color"`
+	ParentID       *uint  `json:"parent_id" gorm:"index"`
+	Kind           string `json:"kind" gorm:"not null;default:'expense'"` // expense, income
+	IsDefault      bool   `json:"is_default" gorm:"default:false"`        // Seeded rather than user-created
+
+	// Relationships
+	Children []Category `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+}
+
+// NormalizeCategoryName folds case and whitespace so "Food", "food" and
+// "Food " resolve to the same category
+func NormalizeCategoryName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Category kinds
+const (
+	CategoryKindExpense = "expense"
+	CategoryKindIncome  = "income"
+)
+
+// CategorySeed describes a default category and its subcategories
+type CategorySeed struct {
+	Name     string
+	Icon     string
+	Color    string
+	Children []string
+}
+
+// DefaultCategories are created for every user on first use
+var DefaultCategories = []CategorySeed{
+	{Name: "Food", Icon: "🍔", Color: "#FF6B6B", Children: []string{"Groceries", "Dining Out"}},
+	{Name: "Transportation", Icon: "🚗", Color: "#4ECDC4", Children: []string{"Fuel", "Public Transport", "Cabs"}},
+	{Name: "Shopping", Icon: "🛍️", Color: "#45B7D1", Children: []string{"Clothing", "Electronics"}},
+	{Name: "Bills & Utilities", Icon: "💡", Color: "#F7B731", Children: []string{"Rent", "Electricity", "Mobile & Internet"}},
+	{Name: "Entertainment", Icon: "🎬", Color: "#A55EEA", Children: []string{"Movies", "Subscriptions"}},
+	{Name: "Health", Icon: "💊", Color: "#26DE81", Children: []string{"Medicines", "Doctor"}},
+	{Name: "Education", Icon: "📚", Color: "#FD9644"},
+	{Name: "Travel", Icon: "✈️", Color: "#2D98DA"},
+	{Name: "Adventure", Icon: "🏔️", Color: "#8854D0"},
+	{Name: "Personal Care", Icon: "💇", Color: "#EB3B5A"},
+	{Name: "Household", Icon: "🏠", Color: "#778CA3"},
+	{Name: "Miscellaneous", Icon: "📦", Color: "#A5B1C2"},
+}
+
+// DefaultIncomeCategories are created alongside the expense defaults
+var DefaultIncomeCategories = []CategorySeed{
+	{Name: "Salary", Icon: "💼", Color: "#20BF6B"},
+	{Name: "Freelance", Icon: "💻", Color: "#0FB9B1"},
+	{Name: "Refunds", Icon: "↩️", Color: "#4B7BEC"},
+	{Name: "Interest", Icon: "🏦", Color: "#F7B731"},
+	{Name: "Other Income", Icon: "💰", Color: "#A5B1C2"},
+}
+
+// CategoryRequest represents the payload for creating or editing a category
+type CategoryRequest struct {
+	Name     string `json:"name"`
+	Icon     string `json:"icon"`
+	Color    string `json:"color"`
+	ParentID *uint  `json:"parent_id"`
+	Kind     string `json:"kind"` // expense (default) or income
+}
+
+// MergeCategoryRequest represents the payload for merging one category into another
+type MergeCategoryRequest struct {
+	TargetID uint `json:"target_id" binding:"required"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reimbursement statuses of a reimbursable expense, and of a claim
+const (
+	ReimbursementPending    = "pending"    // Not yet part of a claim
+	ReimbursementClaimed    = "claimed"    // Submitted in a claim, waiting to be paid back
+	ReimbursementReimbursed = "reimbursed" // The claim has been paid back
+)
+
+// Claim groups reimbursable expenses submitted together, e.g. the costs of
+// one work trip sent to an employer. IncomeID is the payment that settled it.
+type Claim struct {
+	gorm.Model
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	Title        string     `json:"title" gorm:"not null"`
+	Reference    string     `json:"reference"` // The employer's claim or expense-report number
+	Notes        string     `json:"notes"`
+	Status       string     `json:"status" gorm:"size:16;not null;default:'claimed'"` // claimed or reimbursed
+	SubmittedAt  time.Time  `json:"submitted_at"`
+	IncomeID     *uint      `json:"income_id" gorm:"index"`
+	ReimbursedAt *time.Time `json:"reimbursed_at"`
+
+	// Filled in when loaded: the expenses' base amounts in the user's base currency
+	Total        Money  `json:"total" gorm:"-"`
+	Currency     string `json:"currency" gorm:"-"`
+	ExpenseCount int    `json:"expense_count" gorm:"-"`
+
+	// Relationships
+	Expenses []Expense `json:"expenses,omitempty" gorm:"foreignKey:ClaimID"`
+	Income   *Income   `json:"income,omitempty" gorm:"foreignKey:IncomeID"`
+}
+
+// CreateClaimRequest is the payload for submitting a claim
+type CreateClaimRequest struct {
+	Title       string    `json:"title" binding:"required"`
+	Reference   string    `json:"reference"`
+	Notes       string    `json:"notes"`
+	SubmittedAt time.Time `json:"submitted_at"` // Defaults to now
+	ExpenseIDs  []uint    `json:"expense_ids" binding:"required"`
+}
+
+// ReimburseClaimRequest links the income that paid back a claim
+type ReimburseClaimRequest struct {
+	IncomeID uint `json:"income_id" binding:"required"`
+}
+
+// ClaimMatch suggests an income that may be the payment for a claim
+type ClaimMatch struct {
+	ClaimID    uint    `json:"claim_id"`
+	ClaimTitle string  `json:"claim_title"`
+	ClaimTotal Money   `json:"claim_total"`
+	Income     Income  `json:"income"`
+	Difference Money   `json:"difference"` // Income minus claim total, in the base currency
+	Confidence float64 `json:"confidence"`
+}
+
+// ClaimReport is a claim with its expenses laid out for a claim form
+type ClaimReport struct {
+	Claim         Claim        `json:"claim"`
+	UserName      string       `json:"user_name"`
+	GeneratedAt   time.Time    `json:"generated_at"`
+	ReceiptCounts map[uint]int `json:"receipt_counts"` // Receipts attached per expense
+}
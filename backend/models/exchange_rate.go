@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ExchangeRate records how many units of QuoteCurrency one unit of
+// BaseCurrency buys, effective from EffectiveDate until a newer rate exists
+type ExchangeRate struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	BaseCurrency  string    `json:"base_currency" gorm:"size:3;not null;uniqueIndex:idx_exchange_rate_pair_date"`
+	QuoteCurrency string    `json:"quote_currency" gorm:"size:3;not null;uniqueIndex:idx_exchange_rate_pair_date"`
+	EffectiveDate time.Time `json:"effective_date" gorm:"type:date;not null;uniqueIndex:idx_exchange_rate_pair_date"`
+	Rate          float64   `json:"rate" gorm:"type:numeric(20,10);not null"`
+	Source        string    `json:"source"` // file, admin
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -1,15 +1,141 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Expense struct {
 	gorm.Model
-	UserID      uint    `json:"user_id" gorm:"not null;index"`
-	Title       string  `json:"title"`
-	Amount      float64 `json:"amount"`
-	Category    string  `json:"category"`
-	Description string  `json:"description"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	Title       string    `json:"title"`
+	Amount      Money     `json:"amount" gorm:"type:bigint;not null"`
+	Currency    string    `json:"currency" gorm:"size:3;not null;default:'INR'"`
+	CategoryID  *uint     `json:"category_id" gorm:"index"`
+	Category    string    `json:"category"` // Name of the linked category, kept in sync on rename
+	Description string    `json:"description"`
+	SpentAt     time.Time `json:"spent_at" gorm:"not null;index"` // When the money was actually spent
+	AccountID   *uint     `json:"account_id" gorm:"index"`        // Account the money came from
+	MerchantID  *uint     `json:"merchant_id" gorm:"index"`       // Payee, matched from the title when not given
+
+	// Where the expense was logged, when the app knows. The composite index
+	// serves the bounding-box prefilter of radius queries.
+	Latitude  *float64 `json:"latitude" gorm:"index:idx_expense_location,priority:1"`
+	Longitude *float64 `json:"longitude" gorm:"index:idx_expense_location,priority:2"`
+	PlaceName string   `json:"place_name"`
+
+	// Amount converted into the user's base currency at the spend date
+	BaseAmount   Money   `json:"base_amount" gorm:"type:bigint;not null"`
+	BaseCurrency string  `json:"base_currency" gorm:"size:3;not null;default:'INR'"`
+	ExchangeRate float64 `json:"exchange_rate" gorm:"type:numeric(20,10);not null;default:1"`
+
+	RecurringExpenseID *uint `json:"recurring_expense_id" gorm:"index"` // Set when generated by a recurring rule
+	ImportBatchID      *uint `json:"import_batch_id" gorm:"index"`      // Set when imported from a bank statement
+
+	// Work expenses the user fronted and expects to be paid back. The status
+	// moves from pending to claimed when the expense goes into a claim, and
+	// to reimbursed once the claim is paid; it is empty otherwise.
+	Reimbursable        bool   `json:"reimbursable" gorm:"not null;default:false"`
+	ReimbursementStatus string `json:"reimbursement_status" gorm:"size:16;not null;default:'';index"`
+	ClaimID             *uint  `json:"claim_id" gorm:"index"`
+
+	// Income Tax Act section the spending is deductible under, e.g. 80C
+	TaxSection string `json:"tax_section" gorm:"size:16;not null;default:'';index"`
+
+	// Bumped on every edit and sent as the ETag, so a client editing a stale
+	// copy gets 412 instead of overwriting someone else's change
+	Version uint `json:"version" gorm:"not null;default:1"`
 
 	// Relationships
-	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	User User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Tags []Tag `json:"tags" gorm:"many2many:expense_tags"`
+
+	// Line items splitting the expense across categories, e.g. one
+	// supermarket bill. When present their amounts sum to Amount.
+	Items []ExpenseItem `json:"items,omitempty" gorm:"foreignKey:ExpenseID"`
+
+	// Tag names to attach on create/update; missing tags are created
+	TagNames []string `json:"tag_names,omitempty" gorm:"-"`
+
+	// UPI VPA of the payee on create, used to recognize the merchant
+	VPA string `json:"vpa,omitempty" gorm:"-"`
+}
+
+// ExpenseItem is one line of an itemized expense with its own category.
+// BaseAmount is the item's share of the expense's converted amount.
+type ExpenseItem struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ExpenseID  uint      `json:"expense_id" gorm:"not null;index"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	Position   int       `json:"position" gorm:"not null;default:0"`
+	Title      string    `json:"title"`
+	Amount     Money     `json:"amount" gorm:"type:bigint;not null"`
+	BaseAmount Money     `json:"base_amount" gorm:"type:bigint;not null"`
+	CategoryID *uint     `json:"category_id" gorm:"index"`
+	Category   string    `json:"category"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RecurringExpense is a rule such as rent, a subscription or an EMI that
+// generates Expense rows on a schedule
+type RecurringExpense struct {
+	gorm.Model
+	UserID      uint   `json:"user_id" gorm:"not null;index"`
+	Title       string `json:"title" gorm:"not null"`
+	Amount      Money  `json:"amount" gorm:"type:bigint;not null"`
+	Currency    string `json:"currency" gorm:"size:3;not null;default:'INR'"`
+	CategoryID  *uint  `json:"category_id"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	AccountID   *uint  `json:"account_id"`
+
+	Schedule `gorm:"embedded"`
+}
+
+// UpcomingCharge is a future occurrence of a recurring expense
+type UpcomingCharge struct {
+	RecurringExpenseID uint      `json:"recurring_expense_id"`
+	Title              string    `json:"title"`
+	Amount             Money     `json:"amount"`
+	Currency           string    `json:"currency"`
+	Category           string    `json:"category"`
+	DueAt              time.Time `json:"due_at"`
+}
+
+// NearbyExpense is an expense with its distance from a query point
+type NearbyExpense struct {
+	Expense
+	DistanceMeters float64 `json:"distance_meters"`
+}
+
+// PlaceSpending is the spending at one place, identified by its name or,
+// for unnamed spots, by a small grid cell around the coordinates
+type PlaceSpending struct {
+	PlaceName   string    `json:"place_name"`
+	Latitude    float64   `json:"latitude"` // Average of the expenses' coordinates
+	Longitude   float64   `json:"longitude"`
+	Total       Money     `json:"total"`
+	Count       int       `json:"count"`
+	LastSpentAt time.Time `json:"last_spent_at"`
+}
+
+// DuplicateMatch is an existing expense that looks like the same purchase
+type DuplicateMatch struct {
+	Expense    Expense  `json:"expense"`
+	Confidence float64  `json:"confidence"` // 0-1
+	Reasons    []string `json:"reasons"`
+}
+
+// DuplicateGroup is a set of expenses that look like one purchase logged
+// several times, with the one suggested to keep
+type DuplicateGroup struct {
+	KeepID     uint      `json:"keep_id"`
+	Expenses   []Expense `json:"expenses"`
+	Confidence float64   `json:"confidence"` // Of the strongest match in the group
+}
+
+// MergeExpensesRequest represents the payload for merging duplicates into an expense
+type MergeExpensesRequest struct {
+	DuplicateIDs []uint `json:"duplicate_ids" binding:"required"`
 }
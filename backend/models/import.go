@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImportMapping remembers how a bank's CSV statement is laid out. Columns are
+// header names (case-insensitive) or 1-based column numbers.
+type ImportMapping struct {
+	gorm.Model
+	UserID          uint   `json:"user_id" gorm:"not null;index"`
+	Name            string `json:"name" gorm:"not null"` // Usually the bank, e.g. "HDFC savings"
+	DateColumn      string `json:"date_column"`
+	NarrationColumn string `json:"narration_column"`
+	DebitColumn     string `json:"debit_column"`  // Withdrawals
+	CreditColumn    string `json:"credit_column"` // Deposits
+	AmountColumn    string `json:"amount_column"` // Single signed column, used when debit/credit aren't split
+	DateFormat      string `json:"date_format"`   // Go layout; detected when empty
+	HeaderRow       int    `json:"header_row"`    // 1-based; 0 finds the header automatically, -1 for files without one
+}
+
+// Import batch statuses
+const (
+	ImportStatusCommitted  = "committed"
+	ImportStatusRolledBack = "rolled_back"
+)
+
+// ImportBatch records one committed statement import so it can be rolled back
+type ImportBatch struct {
+	gorm.Model
+	UserID         uint       `json:"user_id" gorm:"not null;index"`
+	MappingID      *uint      `json:"mapping_id"`
+	AccountID      *uint      `json:"account_id"`
+	FileName       string     `json:"file_name"`
+	Status         string     `json:"status" gorm:"not null;default:'committed'"`
+	RowCount       int        `json:"row_count"`
+	ExpenseCount   int        `json:"expense_count"`
+	IncomeCount    int        `json:"income_count"`
+	DuplicateCount int        `json:"duplicate_count"`
+	ErrorCount     int        `json:"error_count"`
+	RolledBackAt   *time.Time `json:"rolled_back_at"`
+}
+
+// ImportRow is one parsed statement line as shown in a preview
+type ImportRow struct {
+	Row         int       `json:"row"` // 1-based line in the file
+	Date        time.Time `json:"date"`
+	Narration   string    `json:"narration"`
+	Amount      Money     `json:"amount"`
+	Direction   string    `json:"direction"`              // debit or credit
+	Duplicate   bool      `json:"duplicate"`              // Matches an existing transaction
+	DuplicateOf *uint     `json:"duplicate_of,omitempty"` // The matching expense, for debit rows
+	Error       string    `json:"error,omitempty"`
+}
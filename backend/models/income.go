@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Income records money coming in: salary, freelance payments, refunds, interest
+type Income struct {
+	gorm.Model
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	Title       string    `json:"title"`
+	Amount      Money     `json:"amount" gorm:"type:bigint;not null"`
+	Currency    string    `json:"currency" gorm:"size:3;not null;default:'INR'"`
+	CategoryID  *uint     `json:"category_id" gorm:"index"`
+	Category    string    `json:"category"` // Name of the linked income category
+	Description string    `json:"description"`
+	ReceivedAt  time.Time `json:"received_at" gorm:"not null;index"`
+	AccountID   *uint     `json:"account_id" gorm:"index"` // Account the money landed in
+
+	// Amount converted into the user's base currency at the received date
+	BaseAmount   Money   `json:"base_amount" gorm:"type:bigint;not null"`
+	BaseCurrency string  `json:"base_currency" gorm:"size:3;not null;default:'INR'"`
+	ExchangeRate float64 `json:"exchange_rate" gorm:"type:numeric(20,10);not null;default:1"`
+
+	RecurringIncomeID *uint `json:"recurring_income_id" gorm:"index"` // Set when generated by a recurring rule
+	ImportBatchID     *uint `json:"import_batch_id" gorm:"index"`     // Set when imported from a bank statement
+}
+
+// RecurringIncome is a rule that generates Income rows on a schedule
+type RecurringIncome struct {
+	gorm.Model
+	UserID      uint   `json:"user_id" gorm:"not null;index"`
+	Title       string `json:"title" gorm:"not null"`
+	Amount      Money  `json:"amount" gorm:"type:bigint;not null"`
+	Currency    string `json:"currency" gorm:"size:3;not null;default:'INR'"`
+	CategoryID  *uint  `json:"category_id"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	AccountID   *uint  `json:"account_id"`
+
+	Schedule `gorm:"embedded"`
+}
+
+// CashFlowMonth summarizes money in and out for one calendar month
+type CashFlowMonth struct {
+	Month       string  `json:"month"` // YYYY-MM
+	Income      Money   `json:"income"`
+	Expense     Money   `json:"expense"`
+	Net         Money   `json:"net"`
+	SavingsRate float64 `json:"savings_rate"` // Net as a percentage of income
+}
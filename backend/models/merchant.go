@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Merchant is a payee that expenses are linked to, so "SWIGGY*ORDER 8821",
+// "Swiggy" and "swiggy@icici" all count as the same place
+type Merchant struct {
+	gorm.Model
+	UserID            uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_merchant_user_name,where:deleted_at IS NULL"`
+	Name              string `json:"name" gorm:"not null"`
+	NormalizedName    string `json:"-" gorm:"not null;uniqueIndex:idx_merchant_user_name,where:deleted_at IS NULL"`
+	DefaultCategoryID *uint  `json:"default_category_id"` // Applied to new expenses that arrive without a category
+	DefaultCategory   string `json:"default_category"`
+
+	// Relationships
+	Aliases []MerchantAlias `json:"aliases,omitempty" gorm:"foreignKey:MerchantID"`
+}
+
+// Merchant alias kinds
+const (
+	MerchantAliasName = "name"
+	MerchantAliasVPA  = "vpa"
+)
+
+// MerchantAlias maps a normalized title or a UPI VPA to a merchant
+type MerchantAlias struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_merchant_alias_user"`
+	MerchantID uint      `json:"merchant_id" gorm:"not null;index"`
+	Alias      string    `json:"alias" gorm:"not null;uniqueIndex:idx_merchant_alias_user"`
+	Kind       string    `json:"kind" gorm:"not null;default:'name'"` // name or vpa
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MerchantRequest represents the payload for creating or editing a merchant
+type MerchantRequest struct {
+	Name              string   `json:"name"`
+	DefaultCategoryID *uint    `json:"default_category_id"` // 0 clears the default
+	DefaultCategory   string   `json:"default_category"`
+	Aliases           []string `json:"aliases"` // Extra titles or VPAs to recognize
+}
+
+// MergeMerchantRequest represents the payload for merging one merchant into another
+type MergeMerchantRequest struct {
+	TargetID uint `json:"target_id" binding:"required"`
+}
+
+// MerchantSummary is a merchant with its spending in the user's base currency
+type MerchantSummary struct {
+	Merchant
+	Total       Money      `json:"total"`
+	Count       int64      `json:"count"`
+	LastSpentAt *time.Time `json:"last_spent_at"`
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used for amounts recorded without an explicit currency
+const DefaultCurrency = "INR"
+
+// Directions of money movement on a bank statement or SMS
+const (
+	DirectionDebit  = "debit"
+	DirectionCredit = "credit"
+)
+
+// Money is an exact amount stored as integer minor units (paise, cents).
+// All supported currencies use two decimal places.
+type Money int64
+
+var errInvalidMoney = errors.New("invalid amount: expected a decimal with at most 2 decimal places")
+
+// ParseMoney parses a decimal string such as "1234.5" or "-10.99" into Money
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errInvalidMoney
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, errInvalidMoney
+	}
+	if hasFrac && (frac == "" || len(frac) > 2) {
+		return 0, errInvalidMoney
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	if whole == "" {
+		whole = "0"
+	}
+	for _, r := range whole + frac {
+		if r < '0' || r > '9' {
+			return 0, errInvalidMoney
+		}
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, errInvalidMoney
+	}
+	cents, _ := strconv.ParseInt(frac, 10, 64)
+	// Negative amounts are checked against the same bound, so every parsed
+	// value can also be negated safely
+	if units > (math.MaxInt64-cents)/100 {
+		return 0, errInvalidMoney
+	}
+
+	value := units*100 + cents
+	if negative {
+		value = -value
+	}
+	return Money(value), nil
+}
+
+// String formats the amount as a decimal with exactly two places
+func (m Money) String() string {
+	value := int64(m)
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/100, value%100)
+}
+
+// Percent returns m as a percentage of total, rounded to two decimal places
+func (m Money) Percent(total Money) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(m)*10000/float64(total)) / 100
+}
+
+// MarshalJSON emits the amount as a decimal string so clients never see float drift
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + m.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts either a decimal string ("12.50") or a JSON number (12.5)
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+
+	text := string(data)
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		unquoted, err := strconv.Unquote(text)
+		if err != nil {
+			return errInvalidMoney
+		}
+		text = unquoted
+	}
+
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
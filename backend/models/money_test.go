@@ -0,0 +1,100 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Money
+		wantErr bool
+	}{
+		{input: "0", want: 0},
+		{input: "12", want: 1200},
+		{input: "12.5", want: 1250},
+		{input: "12.50", want: 1250},
+		{input: "-10.99", want: -1099},
+		{input: "+3.01", want: 301},
+		{input: ".75", want: 75},
+		{input: "  42.00 ", want: 4200},
+		{input: "92233720368547758.07", want: 9223372036854775807},
+		{input: "-92233720368547758.07", want: -9223372036854775807},
+		{input: "92233720368547758.08", wantErr: true},
+		{input: "92233720368547758.99", wantErr: true},
+		{input: "-92233720368547758.99", wantErr: true},
+		{input: "92233720368547759", wantErr: true},
+		{input: "99999999999999999999", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "-", wantErr: true},
+		{input: ".", wantErr: true},
+		{input: "1.", wantErr: true},
+		{input: "1.234", wantErr: true},
+		{input: "1,000", wantErr: true},
+		{input: "1e3", wantErr: true},
+		{input: "--1", wantErr: true},
+		{input: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) = %d, want an error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q) returned error %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		amount Money
+		want   string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{1250, "12.50"},
+		{-1099, "-10.99"},
+		{-5, "-0.05"},
+	}
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(tt.amount), got, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Money
+		wantErr bool
+	}{
+		{input: `"12.50"`, want: 1250},
+		{input: `12.5`, want: 1250},
+		{input: `-3`, want: -300},
+		{input: `"1.234"`, wantErr: true},
+		{input: `"x"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.input), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("unmarshal %s = %d, want an error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("unmarshal %s = %d, %v; want %d", tt.input, got, err, tt.want)
+		}
+	}
+}
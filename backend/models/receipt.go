@@ -0,0 +1,21 @@
+package models
+
+import "gorm.io/gorm"
+
+// Receipt is an image or PDF attached to an expense. The file itself lives in
+// the configured blob store under StorageKey.
+type Receipt struct {
+	gorm.Model
+	UserID       uint   `json:"user_id" gorm:"not null;index"`
+	ExpenseID    uint   `json:"expense_id" gorm:"not null;index"`
+	FileName     string `json:"file_name"`                    // Original name as uploaded
+	ContentType  string `json:"content_type" gorm:"not null"` // Sniffed from the file, not the client header
+	Size         int64  `json:"size" gorm:"not null"`
+	Checksum     string `json:"checksum" gorm:"size:64"` // SHA-256 hex
+	StorageKey   string `json:"-" gorm:"not null"`
+	ThumbnailKey string `json:"-"` // Empty when no thumbnail could be generated
+
+	// Expiring download links, filled in on read
+	URL          string `json:"url,omitempty" gorm:"-"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty" gorm:"-"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// CategoryTotal is the spending in one category over a report period
+type CategoryTotal struct {
+	CategoryID *uint   `json:"category_id"`
+	Category   string  `json:"category"`
+	Total      Money   `json:"total"`
+	Count      int     `json:"count"`
+	Share      float64 `json:"share"` // Percentage of total spending
+}
+
+// MerchantTotal is the spending with one payee over a report period
+type MerchantTotal struct {
+	Merchant string `json:"merchant"`
+	Total    Money  `json:"total"`
+	Count    int    `json:"count"`
+}
+
+// MonthlyReport is the content of a monthly statement. All totals are in
+// Currency, the user's base currency.
+type MonthlyReport struct {
+	Month        string               `json:"month"` // YYYY-MM
+	UserName     string               `json:"user_name"`
+	Currency     string               `json:"currency"`
+	Income       Money                `json:"income"`
+	Expense      Money                `json:"expense"`
+	Net          Money                `json:"net"`
+	SavingsRate  float64              `json:"savings_rate"`
+	Categories   []CategoryTotal      `json:"categories"`
+	Budgets      []BudgetWithSpending `json:"budgets"`
+	Merchants    []MerchantTotal      `json:"merchants"`
+	Transactions []Expense            `json:"transactions"`
+	GeneratedAt  time.Time            `json:"generated_at"`
+}
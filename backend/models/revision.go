@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Expense revision actions
+const (
+	RevisionOriginal = "original" // State before the first recorded edit
+	RevisionUpdate   = "update"
+	RevisionRevert   = "revert"
+	RevisionBulk     = "bulk_update" // Changed by a bulk edit
+	RevisionBulkUndo = "bulk_undo"   // A bulk edit was undone
+)
+
+// ExpenseSnapshot is the user-editable state of an expense at one revision
+type ExpenseSnapshot struct {
+	Title        string         `json:"title"`
+	Amount       Money          `json:"amount"`
+	Currency     string         `json:"currency"`
+	CategoryID   *uint          `json:"category_id"`
+	Category     string         `json:"category"`
+	Description  string         `json:"description"`
+	SpentAt      time.Time      `json:"spent_at"`
+	AccountID    *uint          `json:"account_id"`
+	MerchantID   *uint          `json:"merchant_id"`
+	Latitude     *float64       `json:"latitude"`
+	Longitude    *float64       `json:"longitude"`
+	PlaceName    string         `json:"place_name"`
+	BaseAmount   Money          `json:"base_amount"`
+	BaseCurrency string         `json:"base_currency"`
+	ExchangeRate float64        `json:"exchange_rate"`
+	Reimbursable bool           `json:"reimbursable"`
+	TaxSection   string         `json:"tax_section"`
+	Tags         []string       `json:"tags"`
+	Items        []SnapshotItem `json:"items"`
+}
+
+// SnapshotItem is a line item as recorded in an expense snapshot
+type SnapshotItem struct {
+	Title      string `json:"title"`
+	Amount     Money  `json:"amount"`
+	CategoryID *uint  `json:"category_id"`
+	Category   string `json:"category"`
+}
+
+// FieldChange is one field that differs between two revisions
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// ExpenseRevision records an expense as it was after one edit, so edits can
+// be reviewed and undone
+type ExpenseRevision struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	ExpenseID  uint            `json:"expense_id" gorm:"not null;uniqueIndex:idx_revision_expense_number"`
+	UserID     uint            `json:"user_id" gorm:"not null;index"`
+	Number     int             `json:"number" gorm:"not null;uniqueIndex:idx_revision_expense_number"` // 1 is the original state
+	Action     string          `json:"action" gorm:"not null"`                                         // original, update, revert, bulk_update or bulk_undo
+	RevertedTo *int            `json:"reverted_to,omitempty"`                                          // Revision number restored by a revert
+	Changes    []FieldChange   `json:"changes" gorm:"serializer:json;type:text"`                       // Relative to the previous revision
+	Snapshot   ExpenseSnapshot `json:"snapshot" gorm:"serializer:json;type:text"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
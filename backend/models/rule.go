@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CategoryRule assigns a category and tags to new expenses that match all of
+// its conditions. Rules with no UserID are global and apply to every user
+// after their own rules; they name a category instead of linking one.
+type CategoryRule struct {
+	gorm.Model
+	UserID   *uint  `json:"user_id" gorm:"index"` // nil for global rules
+	Name     string `json:"name"`
+	Priority int    `json:"priority" gorm:"not null;default:0"` // Higher runs first
+	IsActive bool   `json:"is_active" gorm:"default:true"`
+
+	// Conditions; every one that is set must match
+	TitleContains       string `json:"title_contains"`
+	DescriptionContains string `json:"description_contains"`
+	Pattern             string `json:"pattern"`                       // Case-insensitive regexp over title and description
+	MinAmount           *Money `json:"min_amount" gorm:"type:bigint"` // Base-currency amount, inclusive
+	MaxAmount           *Money `json:"max_amount" gorm:"type:bigint"`
+	AccountID           *uint  `json:"account_id"`
+
+	// Actions
+	CategoryID *uint    `json:"category_id"`
+	Category   string   `json:"category"` // Category name; global rules only carry a name
+	AddTags    []string `json:"add_tags" gorm:"serializer:json;type:text"`
+
+	MatchCount    int        `json:"match_count" gorm:"not null;default:0"`
+	LastMatchedAt *time.Time `json:"last_matched_at"`
+}
+
+// IsGlobal reports whether the rule applies to every user
+func (r CategoryRule) IsGlobal() bool {
+	return r.UserID == nil
+}
+
+// CategoryCorrection records a user moving an expense to another category,
+// which is what rule suggestions learn from
+type CategoryCorrection struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	UserID         uint      `json:"user_id" gorm:"not null;index:idx_correction_user_key"`
+	ExpenseID      uint      `json:"expense_id" gorm:"not null"`
+	MerchantKey    string    `json:"merchant_key" gorm:"not null;index:idx_correction_user_key"`
+	FromCategoryID *uint     `json:"from_category_id"`
+	ToCategoryID   uint      `json:"to_category_id" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// RuleSuggestion proposes a rule from repeated corrections of the same merchant
+type RuleSuggestion struct {
+	MerchantKey string       `json:"merchant_key"`
+	CategoryID  uint         `json:"category_id"`
+	Category    string       `json:"category"`
+	Corrections int          `json:"corrections"`
+	Confidence  float64      `json:"confidence"` // Share of the merchant's corrections going to this category
+	Rule        CategoryRule `json:"rule"`       // Ready to POST to /rules
+}
+
+// RuleTestMatch is one historical expense a rule under test would affect
+type RuleTestMatch struct {
+	ExpenseID        uint   `json:"expense_id"`
+	Title            string `json:"title"`
+	Amount           Money  `json:"amount"`
+	Currency         string `json:"currency"`
+	SpentAt          string `json:"spent_at"`
+	CurrentCategory  string `json:"current_category"`
+	ProposedCategory string `json:"proposed_category"`
+	Effective        bool   `json:"effective"` // False when a higher-priority rule would win
+}
+
+// DefaultCategoryRules are the global rules seeded on first start. Category
+// names refer to DefaultCategories.
+var DefaultCategoryRules = []CategoryRule{
+	{Name: "Food delivery", Pattern: `\b(swiggy|zomato|eatsure|dominos|domino's)\b`, Category: "Dining Out"},
+	{Name: "Cabs", Pattern: `\b(uber|ola|rapido|meru)\b`, Category: "Cabs"},
+	{Name: "Grocery apps", Pattern: `\b(bigbasket|blinkit|grofers|zepto|dmart|instamart|jiomart)\b`, Category: "Groceries"},
+	{Name: "Fuel stations", Pattern: `\b(petrol|diesel|fuel|indian ?oil|iocl|hpcl|bpcl|shell)\b`, Category: "Fuel"},
+	{Name: "Metro and buses", Pattern: `\b(metro|bmtc|best bus|dtc|ksrtc|msrtc)\b`, Category: "Public Transport"},
+	{Name: "Streaming", Pattern: `\b(netflix|spotify|hotstar|prime video|youtube premium|sonyliv|zee5)\b`, Category: "Subscriptions"},
+	{Name: "Mobile and broadband", Pattern: `\b(airtel|jio|vodafone|vi recharge|bsnl|act fibernet)\b`, Category: "Mobile & Internet"},
+	{Name: "Electricity boards", Pattern: `\b(electricity|bescom|msedcl|tata power|adani electricity|tneb|bses)\b`, Category: "Electricity"},
+	{Name: "Pharmacies", Pattern: `\b(pharmacy|chemist|apollo pharmacy|1mg|pharmeasy|netmeds)\b`, Category: "Medicines"},
+	{Name: "Movie tickets", Pattern: `\b(bookmyshow|pvr|inox|cinepolis)\b`, Category: "Movies"},
+	{Name: "Travel bookings", Pattern: `\b(irctc|makemytrip|goibibo|indigo|air india|vistara|cleartrip|redbus)\b`, Category: "Travel"},
+	{Name: "Online shopping", Pattern: `\b(amazon|flipkart|myntra|ajio|meesho|nykaa)\b`, Category: "Shopping"},
+}
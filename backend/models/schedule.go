@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Recurrence frequencies
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+// Schedule describes when a recurring transaction falls due. Occurrences are
+// computed from StartDate by index, so a rule on the 31st lands on the last
+// day of shorter months without drifting.
+type Schedule struct {
+	Frequency       string     `json:"frequency" gorm:"not null;default:'monthly'"` // daily, weekly, monthly, yearly
+	Interval        int        `json:"interval" gorm:"not null;default:1"`          // Every N periods
+	DayOfMonth      int        `json:"day_of_month"`                                // Monthly/yearly; 0 uses the start date's day
+	StartDate       time.Time  `json:"start_date" gorm:"not null"`
+	EndDate         *time.Time `json:"end_date"`
+	MaxOccurrences  int        `json:"max_occurrences"` // 0 means unlimited
+	OccurrenceCount int        `json:"occurrence_count" gorm:"not null;default:0"`
+	NextDueAt       *time.Time `json:"next_due_at" gorm:"index"` // Nil once the schedule has ended
+	IsPaused        bool       `json:"is_paused" gorm:"default:false"`
+	FailureCount    int        `json:"failure_count" gorm:"not null;default:0"` // Failed generation runs in a row
+	LastError       string     `json:"last_error"`                              // Why the last run failed
+}
+
+// UpdateRecurringRequest is the payload for editing a recurring expense or
+// income rule. Only the fields sent change; category_id, account_id and
+// end_date can be sent as null to clear them.
+type UpdateRecurringRequest struct {
+	Title          *string    `json:"title"`
+	Amount         *Money     `json:"amount"`
+	Currency       *string    `json:"currency"`
+	CategoryID     *uint      `json:"category_id"`
+	Category       *string    `json:"category"`
+	Description    *string    `json:"description"`
+	AccountID      *uint      `json:"account_id"`
+	Frequency      *string    `json:"frequency"`
+	Interval       *int       `json:"interval"`
+	DayOfMonth     *int       `json:"day_of_month"`
+	StartDate      *time.Time `json:"start_date"`
+	EndDate        *time.Time `json:"end_date"`
+	MaxOccurrences *int       `json:"max_occurrences"` // 0 removes the limit
+	IsPaused       *bool      `json:"is_paused"`       // Resuming skips what fell due while paused
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// SMSMessage is one raw bank or UPI SMS as received on the phone
+type SMSMessage struct {
+	Text       string     `json:"text"`
+	Sender     string     `json:"sender"`      // Sender ID such as "VM-HDFCBK", helps identify the bank
+	ReceivedAt *time.Time `json:"received_at"` // Used when the message itself carries no date
+}
+
+// ParseSMSRequest accepts a single message or a batch
+type ParseSMSRequest struct {
+	SMSMessage
+	Messages []SMSMessage `json:"messages"`
+}
+
+// SMSDraft is a transaction extracted from an SMS for the user to confirm.
+// Debits are drafted as expenses and credits as income.
+type SMSDraft struct {
+	Index         int       `json:"index"` // Position in the request
+	Kind          string    `json:"kind"`  // expense or income
+	Direction     string    `json:"direction"`
+	Title         string    `json:"title"`
+	Amount        Money     `json:"amount"`
+	Currency      string    `json:"currency"`
+	Merchant      string    `json:"merchant"`
+	MerchantID    *uint     `json:"merchant_id"` // Known merchant matching the payee, if any
+	VPA           string    `json:"vpa,omitempty"`
+	AccountSuffix string    `json:"account_suffix,omitempty"`
+	AccountID     *uint     `json:"account_id"` // Matched by account number suffix
+	Date          time.Time `json:"date"`
+	Bank          string    `json:"bank,omitempty"`
+	Rule          string    `json:"rule"`       // Which rule produced the draft
+	Confidence    float64   `json:"confidence"` // 0-1
+}
+
+// SMSSkipped reports a message that wasn't turned into a draft
+type SMSSkipped struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+}
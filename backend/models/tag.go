@@ -0,0 +1,34 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Tag labels expenses across categories, e.g. "goa-trip" or "office"
+type Tag struct {
+	gorm.Model
+	UserID uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_tag_user_name,where:deleted_at IS NULL"`
+	Name   string `json:"name" gorm:"not null;uniqueIndex:idx_tag_user_name,where:deleted_at IS NULL"`
+	Color  string `json:"color"`
+}
+
+// NormalizeTagName lower-cases a tag and joins words with hyphens ("Goa Trip" -> "goa-trip")
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
+
+// TagRequest represents the payload for creating or renaming a tag
+type TagRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// TagSummary is the spending total for one tag over a period
+type TagSummary struct {
+	TagID uint   `json:"tag_id"`
+	Name  string `json:"name"`
+	Total Money  `json:"total"`
+	Count int64  `json:"count"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// TaxSection is a deduction section of the Indian Income Tax Act that
+// spending can be claimed under. A zero Limit means the section has no cap.
+type TaxSection struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Limit       Money  `json:"limit"`
+	SeniorLimit Money  `json:"senior_limit,omitempty"` // Higher limit when the insured are senior citizens
+}
+
+// TaxSectionSummary totals a financial year's spending under one section
+type TaxSectionSummary struct {
+	TaxSection
+	Count      int    `json:"count"`
+	Total      Money  `json:"total"`      // Eligible spending recorded
+	Deductible Money  `json:"deductible"` // Total capped at the limit
+	Remaining  *Money `json:"remaining"`  // Headroom left under the limit; null when uncapped
+}
+
+// TaxSummaryExpense is one expense counted in a tax summary, in rupees
+type TaxSummaryExpense struct {
+	ExpenseID uint      `json:"expense_id"`
+	Section   string    `json:"section"`
+	SpentAt   time.Time `json:"spent_at"`
+	Title     string    `json:"title"`
+	Category  string    `json:"category"`
+	Amount    Money     `json:"amount"`
+}
+
+// TaxSummary is the section-wise deduction summary of one financial year
+// (April to March), in rupees
+type TaxSummary struct {
+	FinancialYear   string              `json:"financial_year"` // e.g. 2024-25
+	From            time.Time           `json:"from"`
+	To              time.Time           `json:"to"` // Exclusive
+	Currency        string              `json:"currency"`
+	UserName        string              `json:"user_name"`
+	GeneratedAt     time.Time           `json:"generated_at"`
+	Sections        []TaxSectionSummary `json:"sections"`
+	TotalDeductible Money               `json:"total_deductible"`
+	Expenses        []TaxSummaryExpense `json:"expenses"`
+	Unconverted     []uint              `json:"unconverted,omitempty"` // Expenses left out for want of an exchange rate to INR
+	Reimbursed      []uint              `json:"reimbursed,omitempty"`  // Expenses left out because they were paid back
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Trash entry types
+const (
+	TrashTypeExpense = "expense"
+	TrashTypeBudget  = "budget"
+)
+
+// TrashEntry is a soft-deleted expense or budget that can be restored until
+// PurgeAt, when it is deleted permanently
+type TrashEntry struct {
+	Type      string    `json:"type"` // expense or budget
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"` // Expense title or budget category/tag
+	Amount    Money     `json:"amount"`
+	Currency  string    `json:"currency"`
+	Date      time.Time `json:"date"` // When the money was spent, or the budget's start
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+
+	Expense *Expense `json:"expense,omitempty"`
+	Budget  *Budget  `json:"budget,omitempty"`
+}
@@ -12,6 +12,7 @@ type User struct {
 	Name         string         `json:"name" gorm:"not null"`
 	PIN          string         `json:"-" gorm:"not null"` // Store hashed PIN, exclude from JSON
 	IsVerified   bool           `json:"is_verified" gorm:"default:false"`
+	BaseCurrency string         `json:"base_currency" gorm:"size:3;not null;default:'INR'"` // Currency budgets and reports use
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
@@ -86,3 +87,8 @@ type OTPResponse struct {
 	Message   string `json:"message"`
 	ExpiresIn int    `json:"expires_in"` // seconds
 }
+
+// UpdateBaseCurrencyRequest represents the request for changing the reporting currency
+type UpdateBaseCurrencyRequest struct {
+	BaseCurrency string `json:"base_currency" binding:"required"`
+}
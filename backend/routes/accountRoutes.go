@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAccountRoutes(r *gin.Engine) {
+	// Protected account routes - require JWT authentication
+	accountGroup := r.Group("/accounts")
+	accountGroup.Use(middleware.AuthMiddleware())
+	{
+		accountGroup.GET("", controllers.GetAccounts)
+		accountGroup.POST("", controllers.CreateAccount)
+		accountGroup.GET("/:id", controllers.GetAccountByID)
+		accountGroup.GET("/:id/ledger", controllers.GetAccountLedger)
+		accountGroup.PUT("/:id", controllers.UpdateAccount)
+		accountGroup.DELETE("/:id", controllers.DeleteAccount)
+	}
+
+	// Transfers between accounts don't count as spending
+	transferGroup := r.Group("/transfers")
+	transferGroup.Use(middleware.AuthMiddleware())
+	{
+		transferGroup.GET("", controllers.GetTransfers)
+		transferGroup.POST("", controllers.CreateTransfer)
+		transferGroup.DELETE("/:id", controllers.DeleteTransfer)
+	}
+}
@@ -33,5 +33,6 @@ func RegisterAuthRoutes(r *gin.Engine) {
 	protectedAuthGroup.Use(middleware.AuthMiddleware())
 	{
 		protectedAuthGroup.GET("/profile", authController.GetProfile)
+		protectedAuthGroup.PUT("/profile/base-currency", controllers.UpdateBaseCurrency)
 	}
 }
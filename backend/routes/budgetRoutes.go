@@ -20,6 +20,8 @@ func RegisterBudgetRoutes(r *gin.Engine) {
 		budgetGroup.GET("", controllers.GetBudgets)
 		budgetGroup.GET("/:id", controllers.GetBudgetByID)
 		budgetGroup.PUT("/:id", controllers.UpdateBudget)
+		budgetGroup.PATCH("/:id", controllers.PatchBudget)
 		budgetGroup.DELETE("/:id", controllers.DeleteBudget)
+		budgetGroup.POST("/:id/restore", controllers.RestoreBudget)
 	}
 }
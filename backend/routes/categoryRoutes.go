@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCategoryRoutes(r *gin.Engine) {
+	// Protected category routes - require JWT authentication
+	categoryGroup := r.Group("/categories")
+	categoryGroup.Use(middleware.AuthMiddleware())
+	{
+		categoryGroup.GET("", controllers.GetCategories)
+		categoryGroup.POST("", controllers.CreateCategory)
+		categoryGroup.PUT("/:id", controllers.UpdateCategory)
+		categoryGroup.DELETE("/:id", controllers.DeleteCategory)
+		categoryGroup.POST("/:id/merge", controllers.MergeCategory)
+	}
+}
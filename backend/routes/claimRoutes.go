@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterClaimRoutes(r *gin.Engine) {
+	// Protected reimbursement claim routes - require JWT authentication
+	claimGroup := r.Group("/claims")
+	claimGroup.Use(middleware.AuthMiddleware())
+	{
+		// Income matching (must come before parameterized routes)
+		claimGroup.GET("/matches", controllers.GetClaimMatches)
+
+		claimGroup.GET("", controllers.GetClaims)
+		claimGroup.POST("", controllers.CreateClaim)
+		claimGroup.GET("/:id", controllers.GetClaim)
+		claimGroup.DELETE("/:id", controllers.DeleteClaim)
+		claimGroup.GET("/:id/report", controllers.GetClaimReport)
+		claimGroup.POST("/:id/reimburse", controllers.ReimburseClaim)
+		claimGroup.DELETE("/:id/reimburse", controllers.UndoClaimReimbursement)
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCurrencyRoutes(r *gin.Engine) {
+	// Protected exchange rate lookups - require JWT authentication
+	rateGroup := r.Group("/exchange-rates")
+	rateGroup.Use(middleware.AuthMiddleware())
+	{
+		rateGroup.GET("", controllers.GetExchangeRates)
+	}
+
+	// Admin-only rate management
+	adminGroup := r.Group("/admin")
+	adminGroup.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		adminGroup.POST("/exchange-rates", controllers.ImportExchangeRates)
+	}
+}
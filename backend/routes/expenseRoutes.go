@@ -12,9 +12,43 @@ func RegisterExpenseRoutes(r *gin.Engine) {
 	expenseGroup := r.Group("/expenses")
 	expenseGroup.Use(middleware.AuthMiddleware())
 	{
+		// Recurring rules and upcoming charges (must come before parameterized routes)
+		expenseGroup.GET("/upcoming", controllers.GetUpcomingCharges)
+		expenseGroup.GET("/recurring", controllers.GetRecurringExpenses)
+		expenseGroup.POST("/recurring", controllers.CreateRecurringExpense)
+		expenseGroup.PUT("/recurring/:id", controllers.UpdateRecurringExpense)
+		expenseGroup.POST("/recurring/:id/skip", controllers.SkipRecurringExpense)
+		expenseGroup.POST("/recurring/:id/pause", controllers.PauseRecurringExpense)
+		expenseGroup.POST("/recurring/:id/resume", controllers.ResumeRecurringExpense)
+		expenseGroup.DELETE("/recurring/:id", controllers.DeleteRecurringExpense)
+
+		// Location-based spending
+		expenseGroup.GET("/nearby", controllers.GetNearbyExpenses)
+		expenseGroup.GET("/places", controllers.GetPlaceSpending)
+
+		// Duplicate detection
+		expenseGroup.GET("/duplicates", controllers.GetDuplicateExpenses)
+
+		// Bulk edits
+		expenseGroup.POST("/bulk-update", controllers.BulkUpdateExpenses)
+		expenseGroup.GET("/bulk-updates", controllers.GetBulkOperations)
+		expenseGroup.POST("/bulk-updates/:id/undo", controllers.UndoBulkOperation)
+
 		expenseGroup.POST("", controllers.CreateExpense)
 		expenseGroup.GET("", controllers.GetExpenses)
+		expenseGroup.GET("/:id", controllers.GetExpenseByID)
 		expenseGroup.PUT("/:id", controllers.UpdateExpense)
+		expenseGroup.PATCH("/:id", controllers.PatchExpense)
 		expenseGroup.DELETE("/:id", controllers.DeleteExpense)
+		expenseGroup.POST("/:id/restore", controllers.RestoreExpense)
+		expenseGroup.POST("/:id/merge", controllers.MergeExpenses)
+
+		// Edit history
+		expenseGroup.GET("/:id/revisions", controllers.GetExpenseRevisions)
+		expenseGroup.POST("/:id/revisions/:number/revert", controllers.RevertExpense)
+
+		// Receipt attachments
+		expenseGroup.POST("/:id/receipts", controllers.UploadReceipt)
+		expenseGroup.GET("/:id/receipts", controllers.GetExpenseReceipts)
 	}
 }
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterExportRoutes(r *gin.Engine) {
+	// Protected export routes - require JWT authentication
+	exportGroup := r.Group("/exports")
+	exportGroup.Use(middleware.AuthMiddleware())
+	{
+		exportGroup.GET("/expenses", controllers.ExportExpenses)
+		exportGroup.GET("/budgets", controllers.ExportBudgets)
+	}
+}
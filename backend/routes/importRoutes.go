@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterImportRoutes(r *gin.Engine) {
+	// Protected statement import routes - require JWT authentication
+	importGroup := r.Group("/imports")
+	importGroup.Use(middleware.AuthMiddleware())
+	{
+		// Saved column mappings per bank (must come before parameterized routes)
+		importGroup.GET("/mappings", controllers.GetImportMappings)
+		importGroup.POST("/mappings", controllers.CreateImportMapping)
+		importGroup.PUT("/mappings/:id", controllers.UpdateImportMapping)
+		importGroup.DELETE("/mappings/:id", controllers.DeleteImportMapping)
+
+		importGroup.POST("/preview", controllers.PreviewImport)
+		importGroup.POST("", controllers.CommitImport)
+		importGroup.GET("", controllers.GetImportBatches)
+		importGroup.POST("/:id/rollback", controllers.RollbackImport)
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterIncomeRoutes(r *gin.Engine) {
+	// Protected income routes - require JWT authentication
+	incomeGroup := r.Group("/incomes")
+	incomeGroup.Use(middleware.AuthMiddleware())
+	{
+		// Recurring rules (must come before parameterized routes)
+		incomeGroup.GET("/recurring", controllers.GetRecurringIncomes)
+		incomeGroup.POST("/recurring", controllers.CreateRecurringIncome)
+		incomeGroup.PUT("/recurring/:id", controllers.UpdateRecurringIncome)
+		incomeGroup.DELETE("/recurring/:id", controllers.DeleteRecurringIncome)
+
+		incomeGroup.GET("", controllers.GetIncomes)
+		incomeGroup.POST("", controllers.CreateIncome)
+		incomeGroup.PUT("/:id", controllers.UpdateIncome)
+		incomeGroup.DELETE("/:id", controllers.DeleteIncome)
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterMerchantRoutes(r *gin.Engine) {
+	// Protected merchant routes - require JWT authentication
+	merchantGroup := r.Group("/merchants")
+	merchantGroup.Use(middleware.AuthMiddleware())
+	{
+		merchantGroup.GET("", controllers.GetMerchants)
+		merchantGroup.POST("", controllers.CreateMerchant)
+		merchantGroup.GET("/:id", controllers.GetMerchant)
+		merchantGroup.PUT("/:id", controllers.UpdateMerchant)
+		merchantGroup.DELETE("/:id", controllers.DeleteMerchant)
+		merchantGroup.GET("/:id/expenses", controllers.GetMerchantExpenses)
+		merchantGroup.POST("/:id/merge", controllers.MergeMerchant)
+		merchantGroup.DELETE("/:id/aliases/:aliasId", controllers.DeleteMerchantAlias)
+	}
+}
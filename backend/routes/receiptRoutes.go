@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterReceiptRoutes(r *gin.Engine) {
+	// Public download route - authorized by the signed, expiring link
+	r.GET("/receipts/:id/download", controllers.DownloadReceipt)
+
+	// Protected receipt routes - require JWT authentication
+	receiptGroup := r.Group("/receipts")
+	receiptGroup.Use(middleware.AuthMiddleware())
+	{
+		receiptGroup.GET("/:id/url", controllers.GetReceiptURL)
+		receiptGroup.DELETE("/:id", controllers.DeleteReceipt)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterReportRoutes(r *gin.Engine) {
+	// Protected report routes - require JWT authentication
+	reportGroup := r.Group("/reports")
+	reportGroup.Use(middleware.AuthMiddleware())
+	{
+		reportGroup.GET("/cash-flow", controllers.GetCashFlow)
+		reportGroup.GET("/monthly/:month", controllers.GetMonthlyReport)
+	}
+}
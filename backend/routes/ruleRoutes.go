@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRuleRoutes(r *gin.Engine) {
+	// Protected categorization rule routes - require JWT authentication
+	ruleGroup := r.Group("/rules")
+	ruleGroup.Use(middleware.AuthMiddleware())
+	{
+		ruleGroup.GET("", controllers.GetRules)
+		ruleGroup.POST("", controllers.CreateRule)
+		ruleGroup.GET("/suggestions", controllers.GetRuleSuggestions)
+		ruleGroup.POST("/test", controllers.TestRule)
+		ruleGroup.PUT("/:id", controllers.UpdateRule)
+		ruleGroup.DELETE("/:id", controllers.DeleteRule)
+	}
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSMSRoutes(r *gin.Engine) {
+	// Protected SMS routes - require JWT authentication
+	smsGroup := r.Group("/sms")
+	smsGroup.Use(middleware.AuthMiddleware())
+	{
+		smsGroup.POST("/parse", controllers.ParseSMS)
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTagRoutes(r *gin.Engine) {
+	// Protected tag routes - require JWT authentication
+	tagGroup := r.Group("/tags")
+	tagGroup.Use(middleware.AuthMiddleware())
+	{
+		// Tag reporting (must come before parameterized routes)
+		tagGroup.GET("/summary", controllers.GetTagSummary)
+
+		tagGroup.GET("", controllers.GetTags)
+		tagGroup.POST("", controllers.CreateTag)
+		tagGroup.PUT("/:id", controllers.UpdateTag)
+		tagGroup.DELETE("/:id", controllers.DeleteTag)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTaxRoutes(r *gin.Engine) {
+	// Protected tax deduction routes - require JWT authentication
+	taxGroup := r.Group("/tax")
+	taxGroup.Use(middleware.AuthMiddleware())
+	{
+		taxGroup.GET("/sections", controllers.GetTaxSections)
+		taxGroup.GET("/summary", controllers.GetTaxSummary)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"finance-app-backend/controllers"
+	"finance-app-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTrashRoutes(r *gin.Engine) {
+	// Protected trash routes - require JWT authentication
+	trashGroup := r.Group("/trash")
+	trashGroup.Use(middleware.AuthMiddleware())
+	{
+		trashGroup.GET("", controllers.GetTrash)
+		trashGroup.DELETE("", controllers.EmptyTrash)
+	}
+}
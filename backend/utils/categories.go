@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"finance-app-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// EnsureDefaultCategories seeds the default expense and income category trees
+// for a user the first time they need categories. Kinds the user already has
+// categories for are left alone.
+func EnsureDefaultCategories(db *gorm.DB, userID uint) error {
+	seeds := map[string][]models.CategorySeed{
+		models.CategoryKindExpense: models.DefaultCategories,
+		models.CategoryKindIncome:  models.DefaultIncomeCategories,
+	}
+
+	for _, kind := range []string{models.CategoryKindExpense, models.CategoryKindIncome} {
+		var count int64
+		if err := db.Unscoped().Model(&models.Category{}).Where("user_id = ? AND kind = ?", userID, kind).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		if err := seedCategories(db, userID, kind, seeds[kind]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedCategories creates a set of default categories and their children
+func seedCategories(db *gorm.DB, userID uint, kind string, seeds []models.CategorySeed) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, seed := range seeds {
+			parent := models.Category{
+				UserID:         userID,
+				Name:           seed.Name,
+				NormalizedName: models.NormalizeCategoryName(seed.Name),
+				Icon:           seed.Icon,
+				Color:          seed.Color,
+				Kind:           kind,
+				IsDefault:      true,
+			}
+			if err := tx.Create(&parent).Error; err != nil {
+				return err
+			}
+
+			for _, childName := range seed.Children {
+				child := models.Category{
+					UserID:         userID,
+					Name:           childName,
+					NormalizedName: models.NormalizeCategoryName(childName),
+					Icon:           seed.Icon,
+					Color:          seed.Color,
+					ParentID:       &parent.ID,
+					Kind:           kind,
+					IsDefault:      true,
+				}
+				if err := tx.Create(&child).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// CategoryTreeIDs returns the category and all of its descendants
+func CategoryTreeIDs(db *gorm.DB, categoryID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Raw(`WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+		) SELECT id FROM tree`, categoryID).Scan(&ids).Error
+	return ids, err
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"finance-app-backend/models"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// claimAmountTolerance is how far, as a fraction of the claim total, a
+// reimbursement may differ from it, e.g. for transfer charges or rounding
+const claimAmountTolerance = 0.02
+
+// reimbursementWords often appear in the narration of a reimbursement payment
+var reimbursementWords = []string{"reimb", "claim", "expense", "travel", "tada"}
+
+// MatchClaimIncome scores how likely an income is the payment for a claim,
+// from 0 to 1. It is 0 unless the income arrived no earlier than the day the
+// claim was submitted and is within 2% of the claim total. Exact amounts and
+// narrations naming the claim reference or a reimbursement score higher.
+func MatchClaimIncome(total models.Money, submittedAt time.Time, reference string, income models.Income) float64 {
+	if total <= 0 {
+		return 0
+	}
+	submitted := submittedAt.In(time.Local)
+	if income.ReceivedAt.Before(time.Date(submitted.Year(), submitted.Month(), submitted.Day(), 0, 0, 0, 0, time.Local)) {
+		return 0
+	}
+	difference := math.Abs(float64(income.BaseAmount - total))
+	if difference > float64(total)*claimAmountTolerance {
+		return 0
+	}
+
+	confidence := 0.6
+	if difference == 0 {
+		confidence = 0.8
+	}
+	text := strings.ToLower(income.Title + " " + income.Description)
+	if reference != "" && strings.Contains(text, strings.ToLower(reference)) {
+		confidence += 0.2
+	} else {
+		for _, word := range reimbursementWords {
+			if strings.Contains(text, word) {
+				confidence += 0.1
+				break
+			}
+		}
+	}
+	return math.Round(confidence*100) / 100
+}
+
+// ClaimCSVHeader is the column layout of claim report CSVs
+var ClaimCSVHeader = []string{
+	"expense_id", "date", "title", "category", "description",
+	"amount", "currency", "base_amount", "base_currency", "receipts",
+}
+
+// ClaimCSVRecord formats one expense of a claim as a CSV row
+func ClaimCSVRecord(expense models.Expense, receipts int) []string {
+	return []string{
+		strconv.FormatUint(uint64(expense.ID), 10),
+		expense.SpentAt.Format("2006-01-02"),
+		csvSafe(expense.Title),
+		csvSafe(expense.Category),
+		csvSafe(expense.Description),
+		expense.Amount.String(),
+		expense.Currency,
+		expense.BaseAmount.String(),
+		expense.BaseCurrency,
+		strconv.Itoa(receipts),
+	}
+}
+
+// WriteClaimReportPDF renders a claim as a PDF claim form listing its expenses
+func WriteClaimReportPDF(w io.Writer, report models.ClaimReport) error {
+	l := &reportLayout{pdf: NewPDF()}
+	l.newPage()
+
+	claim := report.Claim
+	money := func(amount models.Money) string {
+		return FormatReportMoney(amount, claim.Currency)
+	}
+
+	// Title block
+	l.y += 10
+	l.pdf.Text(reportMargin, l.y, 18, true, "CapiFy Expense Claim")
+	l.pdf.TextRight(reportMargin+reportWidth, l.y, 12, true, FitText(claim.Title, 12, 240))
+	l.y += 16
+	l.pdf.Text(reportMargin, l.y, 10, false, report.UserName)
+	l.pdf.TextRight(reportMargin+reportWidth, l.y, 8, false,
+		"Generated "+report.GeneratedAt.Format("02 Jan 2006 15:04")+" - amounts in "+claim.Currency)
+	l.y += 10
+	l.pdf.Line(reportMargin, l.y, reportMargin+reportWidth, l.y, 1, 0.2)
+	l.y += 8
+
+	// Summary figures
+	status := "Awaiting payment"
+	if claim.Status == models.ReimbursementReimbursed && claim.ReimbursedAt != nil {
+		status = "Paid " + claim.ReimbursedAt.Format("02 Jan 2006")
+	}
+	summary := []struct{ label, value string }{
+		{"Total claimed", money(claim.Total)},
+		{"Expenses", strconv.Itoa(claim.ExpenseCount)},
+		{"Submitted", claim.SubmittedAt.Format("02 Jan 2006")},
+		{"Status", status},
+	}
+	boxWidth := reportWidth / float64(len(summary))
+	for i, item := range summary {
+		x := reportMargin + float64(i)*boxWidth
+		l.pdf.FillRect(x+2, l.y, boxWidth-4, 40, 0.95, 0.96, 0.98)
+		l.pdf.Text(x+10, l.y+14, 8, false, item.label)
+		l.pdf.Text(x+10, l.y+31, 12, true, FitText(item.value, 12, boxWidth-20))
+	}
+	l.y += 50
+
+	if claim.Reference != "" {
+		l.note("Reference: " + claim.Reference)
+	}
+	if claim.Notes != "" {
+		l.note(FitText("Notes: "+claim.Notes, reportRowSize, reportWidth))
+	}
+
+	l.heading("Expenses")
+	rows := make([][]string, 0, len(claim.Expenses)+1)
+	for _, expense := range claim.Expenses {
+		original := ""
+		if expense.Currency != claim.Currency {
+			original = FormatReportMoney(expense.Amount, expense.Currency)
+		}
+		rows = append(rows, []string{
+			expense.SpentAt.Format("02 Jan 2006"), expense.Title, expense.Category, original,
+			strconv.Itoa(report.ReceiptCounts[expense.ID]), money(expense.BaseAmount),
+		})
+	}
+	rows = append(rows, []string{"Total", "", "", "", "", money(claim.Total)})
+	l.table([]reportColumn{
+		{title: "Date", width: 60},
+		{title: "Description", width: 160},
+		{title: "Category", width: 85},
+		{title: "Original", width: 85, right: true},
+		{title: "Receipts", width: 40, right: true},
+		{title: "Amount", width: 85, right: true},
+	}, rows)
+
+	if claim.Income != nil {
+		l.heading("Reimbursement")
+		income := claim.Income
+		l.note(FitText(fmt.Sprintf("Received %s on %s: %s", money(income.BaseAmount),
+			income.ReceivedAt.Format("02 Jan 2006"), income.Title), reportRowSize, reportWidth))
+		if difference := income.BaseAmount - claim.Total; difference != 0 {
+			l.note("Difference from the amount claimed: " + money(difference))
+		}
+	}
+
+	// Page footers go on last, once the page count is known
+	for i, page := range l.pdf.pages {
+		l.pdf.page = page
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(l.pdf.pages))
+		l.pdf.TextRight(reportMargin+reportWidth, PDFPageHeight-25, 8, false, footer)
+		l.pdf.Text(reportMargin, PDFPageHeight-25, 8, false, FitText("CapiFy - "+claim.Title, 8, 300))
+	}
+
+	_, err := l.pdf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,315 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"finance-app-backend/models"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoExchangeRate is returned when no rate is known for a currency pair on a date
+var ErrNoExchangeRate = errors.New("no exchange rate available")
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// NormalizeCurrency upper-cases and validates an ISO 4217 currency code
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !currencyCodePattern.MatchString(code) {
+		return "", fmt.Errorf("invalid currency code %q", code)
+	}
+	return code, nil
+}
+
+// rateDate truncates a timestamp to the calendar day exchange rates are keyed by
+func rateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// directRate looks up the most recent stored rate for base->quote on or before the date
+func directRate(db *gorm.DB, base, quote string, on time.Time) (float64, bool) {
+	var rate models.ExchangeRate
+	err := db.Where("base_currency = ? AND quote_currency = ? AND effective_date <= ?", base, quote, rateDate(on)).
+		Order("effective_date DESC").
+		First(&rate).Error
+	if err != nil || rate.Rate <= 0 {
+		return 0, false
+	}
+	return rate.Rate, true
+}
+
+// pairRate resolves a rate from a direct or inverse quote
+func pairRate(db *gorm.DB, from, to string, on time.Time) (float64, bool) {
+	if rate, ok := directRate(db, from, to, on); ok {
+		return rate, true
+	}
+	if rate, ok := directRate(db, to, from, on); ok {
+		return 1 / rate, true
+	}
+	return 0, false
+}
+
+// FindExchangeRate returns how many units of `to` one unit of `from` buys on the
+// given date, falling back to the inverse quote or a cross rate via INR
+func FindExchangeRate(db *gorm.DB, from, to string, on time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	if rate, ok := pairRate(db, from, to, on); ok {
+		return rate, nil
+	}
+
+	if from != models.DefaultCurrency && to != models.DefaultCurrency {
+		fromPivot, ok1 := pairRate(db, from, models.DefaultCurrency, on)
+		pivotTo, ok2 := pairRate(db, models.DefaultCurrency, to, on)
+		if ok1 && ok2 {
+			return fromPivot * pivotTo, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w for %s to %s on %s", ErrNoExchangeRate, from, to, on.Format("2006-01-02"))
+}
+
+// ConvertMoney converts an amount using a rate, rounding to the nearest minor unit
+func ConvertMoney(amount models.Money, rate float64) models.Money {
+	return models.Money(math.Round(float64(amount) * rate))
+}
+
+// ConvertExpenseToBase fills BaseAmount and ExchangeRate on an expense for the
+// given base currency using the rate in effect on the expense's spend date
+func ConvertExpenseToBase(db *gorm.DB, expense *models.Expense, baseCurrency string) error {
+	rate, err := FindExchangeRate(db, expense.Currency, baseCurrency, expense.SpentAt)
+	if err != nil {
+		return err
+	}
+
+	expense.BaseCurrency = baseCurrency
+	expense.ExchangeRate = rate
+	expense.BaseAmount = ConvertMoney(expense.Amount, rate)
+	return nil
+}
+
+// ConvertIncomeToBase fills BaseAmount and ExchangeRate on an income for the
+// given base currency using the rate in effect on the received date
+func ConvertIncomeToBase(db *gorm.DB, income *models.Income, baseCurrency string) error {
+	rate, err := FindExchangeRate(db, income.Currency, baseCurrency, income.ReceivedAt)
+	if err != nil {
+		return err
+	}
+
+	income.BaseCurrency = baseCurrency
+	income.ExchangeRate = rate
+	income.BaseAmount = ConvertMoney(income.Amount, rate)
+	return nil
+}
+
+// RecalculateBaseAmounts re-converts foreign-currency expenses and incomes dated
+// on or after `since` so newly loaded rates apply retroactively. Rows that still
+// have no usable rate keep their previous conversion. Each expense is saved
+// together with its line items, and its version is bumped so clients holding
+// the old amounts see a conflict. It returns how many rows were changed.
+func RecalculateBaseAmounts(db *gorm.DB, since time.Time) (int64, error) {
+	var expenses []models.Expense
+	err := db.Where("currency <> base_currency AND spent_at >= ?", rateDate(since)).Find(&expenses).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var updated int64
+	for _, expense := range expenses {
+		previousAmount := expense.BaseAmount
+		if err := ConvertExpenseToBase(db, &expense, expense.BaseCurrency); err != nil {
+			if errors.Is(err, ErrNoExchangeRate) {
+				continue
+			}
+			return updated, err
+		}
+		if expense.BaseAmount == previousAmount {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.Expense{}).Where("id = ?", expense.ID).UpdateColumns(map[string]interface{}{
+				"base_amount":   expense.BaseAmount,
+				"exchange_rate": expense.ExchangeRate,
+				"version":       gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return err
+			}
+			return ReallocateItemBaseAmounts(tx, expense.ID, expense.BaseAmount)
+		})
+		if err != nil {
+			return updated, fmt.Errorf("expense %d: %w", expense.ID, err)
+		}
+		updated++
+	}
+
+	var incomes []models.Income
+	err = db.Where("currency <> base_currency AND received_at >= ?", rateDate(since)).Find(&incomes).Error
+	if err != nil {
+		return updated, err
+	}
+
+	for _, income := range incomes {
+		previousAmount := income.BaseAmount
+		if err := ConvertIncomeToBase(db, &income, income.BaseCurrency); err != nil {
+			if errors.Is(err, ErrNoExchangeRate) {
+				continue
+			}
+			return updated, err
+		}
+		if income.BaseAmount == previousAmount {
+			continue
+		}
+
+		if err := db.Model(&models.Income{}).Where("id = ?", income.ID).UpdateColumns(map[string]interface{}{
+			"base_amount":   income.BaseAmount,
+			"exchange_rate": income.ExchangeRate,
+		}).Error; err != nil {
+			return updated, fmt.Errorf("income %d: %w", income.ID, err)
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// ExchangeRateInput is the wire format for importing rates via file or admin API
+type ExchangeRateInput struct {
+	BaseCurrency  string  `json:"base_currency"`
+	QuoteCurrency string  `json:"quote_currency"`
+	EffectiveDate string  `json:"effective_date"` // YYYY-MM-DD
+	Rate          float64 `json:"rate"`
+}
+
+// ToModel validates the input and converts it into a storable rate
+func (in ExchangeRateInput) ToModel(source string) (models.ExchangeRate, error) {
+	base, err := NormalizeCurrency(in.BaseCurrency)
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	quote, err := NormalizeCurrency(in.QuoteCurrency)
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	if base == quote {
+		return models.ExchangeRate{}, fmt.Errorf("base and quote currency are both %s", base)
+	}
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(in.EffectiveDate))
+	if err != nil {
+		return models.ExchangeRate{}, fmt.Errorf("invalid effective_date %q, expected YYYY-MM-DD", in.EffectiveDate)
+	}
+	if in.Rate <= 0 || math.IsInf(in.Rate, 0) || math.IsNaN(in.Rate) {
+		return models.ExchangeRate{}, fmt.Errorf("rate for %s/%s must be positive", base, quote)
+	}
+
+	return models.ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		EffectiveDate: date,
+		Rate:          in.Rate,
+		Source:        source,
+	}, nil
+}
+
+// ParseExchangeRatesJSON reads a JSON array of rates
+func ParseExchangeRatesJSON(r io.Reader) ([]ExchangeRateInput, error) {
+	var inputs []ExchangeRateInput
+	if err := json.NewDecoder(r).Decode(&inputs); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+// ParseExchangeRatesCSV reads rows of "effective_date,base_currency,quote_currency,rate".
+// A header row is skipped when present.
+func ParseExchangeRatesCSV(r io.Reader) ([]ExchangeRateInput, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	var inputs []ExchangeRateInput
+	line := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line++
+
+		if len(record) < 4 {
+			return nil, fmt.Errorf("line %d: expected 4 columns, got %d", line, len(record))
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if err != nil {
+			if line == 1 {
+				continue // header row
+			}
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, record[3])
+		}
+
+		inputs = append(inputs, ExchangeRateInput{
+			EffectiveDate: record[0],
+			BaseCurrency:  record[1],
+			QuoteCurrency: record[2],
+			Rate:          rate,
+		})
+	}
+	return inputs, nil
+}
+
+// SaveExchangeRates validates and upserts rates, then re-converts affected
+// transactions. It returns the number of rates stored and transactions updated.
+func SaveExchangeRates(db *gorm.DB, inputs []ExchangeRateInput, source string) (int, int64, error) {
+	if len(inputs) == 0 {
+		return 0, 0, errors.New("no exchange rates provided")
+	}
+
+	// Later entries for the same pair and date win, as a single upsert
+	// statement cannot touch the same row twice
+	rates := make([]models.ExchangeRate, 0, len(inputs))
+	positions := make(map[string]int)
+	earliest := time.Time{}
+	for i, input := range inputs {
+		rate, err := input.ToModel(source)
+		if err != nil {
+			return 0, 0, fmt.Errorf("rate %d: %w", i+1, err)
+		}
+		if earliest.IsZero() || rate.EffectiveDate.Before(earliest) {
+			earliest = rate.EffectiveDate
+		}
+
+		key := rate.BaseCurrency + rate.QuoteCurrency + rate.EffectiveDate.Format("2006-01-02")
+		if pos, exists := positions[key]; exists {
+			rates[pos] = rate
+			continue
+		}
+		positions[key] = len(rates)
+		rates = append(rates, rate)
+	}
+
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}, {Name: "effective_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_at"}),
+	}).Create(&rates).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	updated, err := RecalculateBaseAmounts(db, earliest)
+	return len(rates), updated, err
+}
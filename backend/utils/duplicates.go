@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"finance-app-backend/models"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultDuplicateWindowDays is how many days apart the same purchase may be
+// dated, e.g. logged by hand on the day and posted by the bank a day later
+const DefaultDuplicateWindowDays = 1
+
+// minDuplicateSimilarity is how alike two titles must be to count as one purchase
+const minDuplicateSimilarity = 0.5
+
+// titleWords lists the distinct meaningful words of a title or narration
+func titleWords(title string) map[string]bool {
+	words := make(map[string]bool)
+	for _, segment := range strings.FieldsFunc(title, func(r rune) bool { return r == '*' || r == '/' || r == '|' }) {
+		for _, word := range merchantWords(segment) {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// TitleSimilarity scores how likely two titles name the same payee, from 0 to
+// 1. Matching merchant keys score 1 and keys sharing their first word ("swiggy
+// dinner" and "swiggy") 0.8; otherwise it is the word overlap. A blank title
+// says nothing either way and scores 0.5.
+func TitleSimilarity(a, b string) float64 {
+	if strings.TrimSpace(a) == "" || strings.TrimSpace(b) == "" {
+		return 0.5
+	}
+	_, keyA, _ := merchantSource(a, "")
+	_, keyB, _ := merchantSource(b, "")
+	if keyA != "" && keyA == keyB {
+		return 1
+	}
+	firstA, _, _ := strings.Cut(keyA, " ")
+	firstB, _, _ := strings.Cut(keyB, " ")
+	if firstA != "" && firstA == firstB {
+		return 0.8
+	}
+
+	wordsA, wordsB := titleWords(a), titleWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+// daysApart counts calendar days between two timestamps in local time
+func daysApart(a, b time.Time) int {
+	a, b = a.In(time.Local), b.In(time.Local)
+	dayA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dayB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(math.Abs(dayA.Sub(dayB).Hours() / 24))
+}
+
+// sameAmount reports whether two expenses are for the same money, either in
+// the currency they were paid in or after conversion
+func sameAmount(a, b models.Expense) bool {
+	if a.Amount == b.Amount && a.Currency == b.Currency {
+		return true
+	}
+	return a.BaseAmount != 0 && a.BaseAmount == b.BaseAmount && a.BaseCurrency == b.BaseCurrency
+}
+
+// MatchDuplicate decides whether two expenses look like the same purchase:
+// the same amount, dated within windowDays of each other, not paid from two
+// different accounts, and with the same merchant or similar titles. It
+// returns the confidence (0-1) and the reasons, or 0 when they don't match.
+func MatchDuplicate(a, b models.Expense, windowDays int) (float64, []string) {
+	if !sameAmount(a, b) {
+		return 0, nil
+	}
+	days := daysApart(a.SpentAt, b.SpentAt)
+	if days > windowDays {
+		return 0, nil
+	}
+	if a.AccountID != nil && b.AccountID != nil && *a.AccountID != *b.AccountID {
+		return 0, nil
+	}
+
+	similarity := TitleSimilarity(a.Title, b.Title)
+	if a.MerchantID != nil && b.MerchantID != nil && *a.MerchantID == *b.MerchantID {
+		similarity = 1
+	}
+	if similarity < minDuplicateSimilarity {
+		return 0, nil
+	}
+
+	reasons := []string{"same amount"}
+	confidence := 0.4 + 0.3*similarity
+	if days == 0 {
+		confidence += 0.2
+		reasons = append(reasons, "same day")
+	} else {
+		confidence += 0.2 * (1 - float64(days)/float64(windowDays+1))
+		reasons = append(reasons, "dated close together")
+	}
+	if similarity == 1 {
+		reasons = append(reasons, "same merchant")
+	} else {
+		reasons = append(reasons, "similar title")
+	}
+	if a.AccountID != nil && b.AccountID != nil {
+		confidence += 0.1
+		reasons = append(reasons, "same account")
+	} else {
+		confidence += 0.05
+	}
+	return math.Round(confidence*100) / 100, reasons
+}
+
+// FindDuplicateCandidates returns the user's existing expenses that look like
+// the same purchase as expense, most likely first
+func FindDuplicateCandidates(db *gorm.DB, expense models.Expense, windowDays int) ([]models.DuplicateMatch, error) {
+	day := expense.SpentAt.In(time.Local)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+
+	query := db.Where("user_id = ? AND spent_at >= ? AND spent_at < ?",
+		expense.UserID, day.AddDate(0, 0, -windowDays), day.AddDate(0, 0, windowDays+1)).
+		Where("(amount = ? AND currency = ?) OR (base_amount = ? AND base_currency = ?)",
+			expense.Amount, expense.Currency, expense.BaseAmount, expense.BaseCurrency)
+	if expense.ID != 0 {
+		query = query.Where("id <> ?", expense.ID)
+	}
+
+	var candidates []models.Expense
+	if err := query.Order("spent_at, id").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	matches := []models.DuplicateMatch{}
+	for _, candidate := range candidates {
+		if confidence, reasons := MatchDuplicate(expense, candidate, windowDays); confidence > 0 {
+			matches = append(matches, models.DuplicateMatch{Expense: candidate, Confidence: confidence, Reasons: reasons})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Confidence > matches[j].Confidence })
+	return matches, nil
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// VersionETag is the entity tag for a version of an expense or budget
+func VersionETag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// ETagMatches reports whether an If-Match header value names etag. The header
+// may list several tags or be "*". Comparison is strong (RFC 9110 13.1.1), so
+// a weak tag never matches.
+func ETagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
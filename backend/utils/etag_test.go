@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestETagMatches(t *testing.T) {
+	etag := VersionETag(3)
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"exact", `"3"`, true},
+		{"wildcard", "*", true},
+		{"in list", `"1", "3"`, true},
+		{"other version", `"2"`, false},
+		{"weak tag", `W/"3"`, false},
+		{"weak tag in list", `"1", W/"3"`, false},
+		{"unquoted", "3", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ETagMatches(tt.header, etag); got != tt.want {
+				t.Errorf("ETagMatches(%q, %q) = %v, want %v", tt.header, etag, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"encoding/xml"
+	"finance-app-backend/models"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportSchemaVersion is bumped whenever an export column or field changes
+// meaning. Columns are only ever appended, never reordered or removed.
+const ExportSchemaVersion = 1
+
+// ExpenseCSVHeader is the column layout of expense CSV exports
+var ExpenseCSVHeader = []string{
+	"id", "date", "spent_at", "title", "amount", "currency",
+	"base_amount", "base_currency", "exchange_rate",
+	"category", "category_id", "account_id", "tags", "description", "merchant_id",
+	"latitude", "longitude", "place_name",
+}
+
+// BudgetCSVHeader is the column layout of budget CSV exports
+var BudgetCSVHeader = []string{
+	"id", "category", "category_id", "tag", "amount", "currency", "period",
+	"start_date", "end_date", "is_active", "current_spent", "remaining", "percentage", "status",
+}
+
+// csvSafe stops spreadsheet apps from evaluating user text as a formula
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// optionalID formats a nullable foreign key as an empty cell when unset
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+// ExpenseCSVRecord formats an expense (with Tags loaded) as a CSV row
+func ExpenseCSVRecord(expense models.Expense) []string {
+	tags := make([]string, len(expense.Tags))
+	for i, tag := range expense.Tags {
+		tags[i] = tag.Name
+	}
+	return []string{
+		strconv.FormatUint(uint64(expense.ID), 10),
+		expense.SpentAt.Format("2006-01-02"),
+		expense.SpentAt.Format(time.RFC3339),
+		csvSafe(expense.Title),
+		expense.Amount.String(),
+		expense.Currency,
+		expense.BaseAmount.String(),
+		expense.BaseCurrency,
+		strconv.FormatFloat(expense.ExchangeRate, 'f', -1, 64),
+		csvSafe(expense.Category),
+		optionalID(expense.CategoryID),
+		optionalID(expense.AccountID),
+		csvSafe(strings.Join(tags, ";")),
+		csvSafe(expense.Description),
+		optionalID(expense.MerchantID),
+		optionalCoordinate(expense.Latitude),
+		optionalCoordinate(expense.Longitude),
+		csvSafe(expense.PlaceName),
+	}
+}
+
+// optionalCoordinate formats a nullable coordinate as an empty cell when unset
+func optionalCoordinate(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+// BudgetCSVRecord formats a budget with its spending as a CSV row
+func BudgetCSVRecord(budget models.BudgetWithSpending) []string {
+	tag := ""
+	if budget.Tag != nil {
+		tag = budget.Tag.Name
+	}
+	return []string{
+		strconv.FormatUint(uint64(budget.ID), 10),
+		csvSafe(budget.Category),
+		optionalID(budget.CategoryID),
+		csvSafe(tag),
+		budget.Amount.String(),
+		budget.Currency,
+		budget.Period,
+		budget.StartDate.Format("2006-01-02"),
+		budget.EndDate.Format("2006-01-02"),
+		strconv.FormatBool(budget.IsActive),
+		budget.CurrentSpent.String(),
+		budget.Remaining.String(),
+		strconv.FormatFloat(budget.Percentage, 'f', 2, 64),
+		budget.Status,
+	}
+}
+
+// ofxTime formats a timestamp the way OFX expects
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405") + "[0:GMT]"
+}
+
+// ofxText escapes and truncates a value for an OFX element
+func ofxText(value string, limit int) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if runes := []rune(value); len(runes) > limit {
+		value = string(runes[:limit])
+	}
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	return escaped.String()
+}
+
+// OFXWriter streams an OFX 2.x bank statement one transaction at a time
+type OFXWriter struct {
+	w        io.Writer
+	currency string
+	total    models.Money
+}
+
+// NewOFXWriter writes the OFX header and opens the statement. accountID
+// identifies the exported account (or "ALL").
+func NewOFXWriter(w io.Writer, currency, accountID string, from, to time.Time) (*OFXWriter, error) {
+	now := time.Now()
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>%d</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS><CURDEF>%s</CURDEF>
+<BANKACCTFROM><BANKID>CAPIFY</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>
+`, ofxTime(now), now.Unix(), currency, ofxText(accountID, 22), ofxTime(from), ofxTime(to))
+	if err != nil {
+		return nil, err
+	}
+	return &OFXWriter{w: w, currency: currency}, nil
+}
+
+// WriteExpense adds an expense as a debit in the statement currency
+func (o *OFXWriter) WriteExpense(expense models.Expense) error {
+	amount := expense.BaseAmount
+	o.total -= amount
+
+	memo := expense.Category
+	if expense.Description != "" {
+		memo = strings.TrimSpace(memo + " " + expense.Description)
+	}
+	_, err := fmt.Fprintf(o.w, "<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>-%s</TRNAMT><FITID>capify-expense-%d</FITID><NAME>%s</NAME><MEMO>%s</MEMO></STMTTRN>\n",
+		ofxTime(expense.SpentAt), amount.String(), expense.ID, ofxText(expense.Title, 32), ofxText(memo, 255))
+	return err
+}
+
+// Close ends the statement. The ledger balance is the net of exported
+// transactions since CapiFy exports aren't tied to a real bank balance.
+func (o *OFXWriter) Close() error {
+	_, err := fmt.Fprintf(o.w, `</BANKTRANLIST>
+<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`, o.total.String(), ofxTime(time.Now()))
+	return err
+}
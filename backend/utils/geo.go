@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"errors"
+	"math"
+)
+
+// earthRadiusMeters is the mean Earth radius used for distance calculations
+const earthRadiusMeters = 6371000.0
+
+// ValidateCoordinates checks a latitude/longitude pair. Both must be given
+// together, or neither.
+func ValidateCoordinates(latitude, longitude *float64) error {
+	if latitude == nil && longitude == nil {
+		return nil
+	}
+	if latitude == nil || longitude == nil {
+		return errors.New("latitude and longitude must be given together")
+	}
+	if math.IsNaN(*latitude) || *latitude < -90 || *latitude > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if math.IsNaN(*longitude) || *longitude < -180 || *longitude > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+// DistanceMeters is the great-circle (haversine) distance between two points
+func DistanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := math.Pi / 180
+	dLat := (lat2 - lat1) * toRadians
+	dLon := (lon2 - lon1) * toRadians
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRadians)*math.Cos(lat2*toRadians)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// BoundingBox returns the latitude and longitude ranges that contain every
+// point within radius meters of the center. Near the poles, or when the box
+// crosses the antimeridian, the longitude range widens to the whole globe.
+func BoundingBox(latitude, longitude, radius float64) (minLat, maxLat, minLon, maxLon float64) {
+	deltaLat := radius / earthRadiusMeters * 180 / math.Pi
+	minLat = math.Max(-90, latitude-deltaLat)
+	maxLat = math.Min(90, latitude+deltaLat)
+
+	cosLat := math.Cos(latitude * math.Pi / 180)
+	if maxLat >= 90 || minLat <= -90 || cosLat < 1e-6 {
+		return minLat, maxLat, -180, 180
+	}
+	deltaLon := deltaLat / cosLat
+	minLon, maxLon = longitude-deltaLon, longitude+deltaLon
+	if minLon < -180 || maxLon > 180 {
+		return minLat, maxLat, -180, 180
+	}
+	return minLat, maxLat, minLon, maxLon
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"finance-app-backend/models"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// maxExpenseItems bounds how many lines one expense can be split into
+const maxExpenseItems = 100
+
+// ValidateExpenseItems checks that every line item has a positive amount and
+// that together they add up to the expense total exactly
+func ValidateExpenseItems(total models.Money, items []models.ExpenseItem) error {
+	if len(items) > maxExpenseItems {
+		return fmt.Errorf("an expense can have at most %d items", maxExpenseItems)
+	}
+
+	var sum models.Money
+	for i := range items {
+		items[i].Title = strings.TrimSpace(items[i].Title)
+		if items[i].Amount <= 0 {
+			return fmt.Errorf("item %d: amount must be greater than zero", i+1)
+		}
+		sum += items[i].Amount
+	}
+	if len(items) > 0 && sum != total {
+		return fmt.Errorf("items add up to %s but the expense amount is %s", sum.String(), total.String())
+	}
+	return nil
+}
+
+// AllocateItemBaseAmounts splits an expense's converted amount across its
+// items in proportion to their amounts. Rounding leftovers go to the items
+// with the largest remainders so the shares always sum to baseAmount.
+func AllocateItemBaseAmounts(items []models.ExpenseItem, baseAmount models.Money) {
+	var total int64
+	for _, item := range items {
+		total += int64(item.Amount)
+	}
+	if total == 0 {
+		return
+	}
+
+	remainders := make([]int64, len(items))
+	order := make([]int, len(items))
+	allocated := models.Money(0)
+	for i, item := range items {
+		share := int64(baseAmount) * int64(item.Amount)
+		items[i].BaseAmount = models.Money(share / total)
+		remainders[i] = share % total
+		allocated += items[i].BaseAmount
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for i := 0; allocated < baseAmount && i < len(order); i++ {
+		items[order[i]].BaseAmount++
+		allocated++
+	}
+}
+
+// ReallocateItemBaseAmounts refreshes the item shares of an expense after its
+// converted amount changed
+func ReallocateItemBaseAmounts(db *gorm.DB, expenseID uint, baseAmount models.Money) error {
+	var items []models.ExpenseItem
+	if err := db.Where("expense_id = ?", expenseID).Order("position, id").Find(&items).Error; err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	AllocateItemBaseAmounts(items, baseAmount)
+	for _, item := range items {
+		if err := db.Model(&models.ExpenseItem{}).Where("id = ?", item.ID).
+			UpdateColumn("base_amount", item.BaseAmount).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"errors"
+	"finance-app-backend/models"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// merchantNoiseWords carry no identity in card and UPI descriptors
+var merchantNoiseWords = map[string]bool{
+	"order": true, "orders": true, "payment": true, "payments": true, "purchase": true, "paid": true,
+	"pvt": true, "ltd": true, "private": true, "limited": true, "llp": true, "inc": true, "india": true,
+	"the": true, "www": true, "com": true, "co": true, "in": true, "to": true, "at": true, "via": true,
+	"upi": true, "pos": true, "ecom": true, "txn": true, "ref": true, "bill": true, "online": true,
+	"dr": true, "cr": true, "neft": true, "imps": true, "rtgs": true, "ach": true, "nach": true, "mb": true,
+}
+
+// merchantProcessors are payment gateways that prefix or suffix the real merchant
+var merchantProcessors = map[string]bool{
+	"payu": true, "razorpay": true, "rzp": true, "paytm": true, "cashfree": true, "ccavenue": true,
+	"billdesk": true, "pg": true, "phonepe": true, "gpay": true, "bharatpe": true, "instamojo": true,
+}
+
+// maxMerchantWords keeps keys short enough that descriptor suffixes don't split merchants
+const maxMerchantWords = 3
+
+var (
+	merchantVPARe   = regexp.MustCompile(`(?i)^[\w.\-]+@[a-z]+$`)
+	merchantFindVPA = regexp.MustCompile(`(?i)[\w.]{2,}@[a-z]{2,}`) // Hyphens usually separate narration fields
+)
+
+// merchantWords extracts the meaningful words of one descriptor segment
+func merchantWords(segment string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(segment), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if len(word) < 2 || merchantNoiseWords[word] || merchantProcessors[word] {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// isPhoneVPA reports whether a VPA handle is mostly a phone number, i.e. a person
+func isPhoneVPA(vpa string) bool {
+	handle := strings.SplitN(vpa, "@", 2)[0]
+	digits := 0
+	for _, r := range handle {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits*2 > len(handle)
+}
+
+// FindVPA returns the first UPI VPA mentioned in text, lower-cased
+func FindVPA(text string) string {
+	return strings.ToLower(merchantFindVPA.FindString(text))
+}
+
+// NormalizeMerchant reduces a title or VPA to a merchant key and a display
+// name: "SWIGGY*ORDER 8821", "Swiggy" and "swiggy@icici" all give "swiggy".
+// Phone-number VPAs are kept whole since they identify a person.
+func NormalizeMerchant(raw string) (string, string) {
+	raw = strings.TrimSpace(raw)
+	if merchantVPARe.MatchString(raw) {
+		if isPhoneVPA(raw) {
+			vpa := strings.ToLower(raw)
+			return vpa, vpa
+		}
+		raw = strings.SplitN(raw, "@", 2)[0]
+	}
+
+	// Card and UPI descriptors separate gateway, merchant and references with
+	// "*" or "/"; the merchant is the first segment with real words in it
+	var words []string
+	for _, segment := range strings.FieldsFunc(raw, func(r rune) bool { return r == '*' || r == '/' || r == '|' }) {
+		if words = merchantWords(segment); len(words) > 0 {
+			break
+		}
+	}
+	if len(words) == 0 {
+		return "", ""
+	}
+	if len(words) > maxMerchantWords {
+		words = words[:maxMerchantWords]
+	}
+
+	display := make([]string, len(words))
+	for i, word := range words {
+		display[i] = capitalize(word)
+	}
+	return strings.Join(words, " "), strings.Join(display, " ")
+}
+
+// capitalize upper-cases the first letter of a word, which may be multibyte
+// as in "élan"
+func capitalize(word string) string {
+	first, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(first)) + word[size:]
+}
+
+// merchantSource picks the VPA (if any) and the key to identify a merchant by
+func merchantSource(title, vpa string) (string, string, string) {
+	vpa = strings.ToLower(strings.TrimSpace(vpa))
+	if vpa == "" {
+		vpa = FindVPA(title)
+	}
+
+	key, display := "", ""
+	if vpa != "" && !isPhoneVPA(vpa) {
+		key, display = NormalizeMerchant(vpa)
+	}
+	if key == "" {
+		key, display = NormalizeMerchant(title)
+	}
+	if key == "" && vpa != "" {
+		key, display = NormalizeMerchant(vpa)
+	}
+	return vpa, key, display
+}
+
+// FindMerchant looks up an existing merchant by VPA, alias or normalized
+// name, falling back to the first word of the key ("swiggy food" -> "swiggy")
+func FindMerchant(db *gorm.DB, userID uint, title, vpa string) (*models.Merchant, error) {
+	vpa, key, _ := merchantSource(title, vpa)
+	if key == "" {
+		return nil, nil
+	}
+
+	var alias models.MerchantAlias
+	aliases := []string{key}
+	if vpa != "" {
+		aliases = append([]string{vpa}, aliases...)
+	}
+	for _, candidate := range aliases {
+		if err := db.Where("user_id = ? AND alias = ?", userID, candidate).First(&alias).Error; err == nil {
+			var merchant models.Merchant
+			if err := db.Where("id = ? AND user_id = ?", alias.MerchantID, userID).First(&merchant).Error; err == nil {
+				return &merchant, nil
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	candidates := []string{key}
+	if first, _, found := strings.Cut(key, " "); found {
+		candidates = append(candidates, first)
+	}
+	for _, candidate := range candidates {
+		var merchant models.Merchant
+		err := db.Where("user_id = ? AND normalized_name = ?", userID, candidate).First(&merchant).Error
+		if err == nil {
+			return &merchant, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// ResolveMerchant finds the merchant for a title and optional VPA, creating
+// one on first sight. A VPA seen for the first time on a known merchant is
+// remembered as an alias. Returns nil when the title has nothing to go on.
+func ResolveMerchant(db *gorm.DB, userID uint, title, vpa string) (*models.Merchant, error) {
+	merchant, err := FindMerchant(db, userID, title, vpa)
+	if err != nil {
+		return nil, err
+	}
+	vpa, key, display := merchantSource(title, vpa)
+
+	if merchant == nil {
+		if key == "" {
+			return nil, nil
+		}
+		merchant = &models.Merchant{UserID: userID, Name: display, NormalizedName: key}
+		if err := db.Create(merchant).Error; err != nil {
+			// Lost a race with a concurrent insert of the same merchant
+			if existing, findErr := FindMerchant(db, userID, title, vpa); findErr == nil && existing != nil {
+				return existing, nil
+			}
+			return nil, err
+		}
+		AddMerchantAlias(db, userID, merchant.ID, key)
+	}
+
+	if vpa != "" {
+		AddMerchantAlias(db, userID, merchant.ID, vpa)
+	}
+	return merchant, nil
+}
+
+// AddMerchantAlias links a title or VPA to a merchant. It returns false when
+// the alias is empty or already belongs to a different merchant.
+func AddMerchantAlias(db *gorm.DB, userID, merchantID uint, raw string) bool {
+	alias, kind := strings.ToLower(strings.TrimSpace(raw)), models.MerchantAliasVPA
+	if !merchantVPARe.MatchString(alias) {
+		alias, _ = NormalizeMerchant(raw)
+		kind = models.MerchantAliasName
+	}
+	if alias == "" {
+		return false
+	}
+
+	var existing models.MerchantAlias
+	if err := db.Where("user_id = ? AND alias = ?", userID, alias).First(&existing).Error; err == nil {
+		return existing.MerchantID == merchantID
+	}
+	return db.Create(&models.MerchantAlias{UserID: userID, MerchantID: merchantID, Alias: alias, Kind: kind}).Error == nil
+}
@@ -0,0 +1,39 @@
+package utils
+
+import "testing"
+
+func TestNormalizeMerchant(t *testing.T) {
+	tests := []struct {
+		raw         string
+		wantKey     string
+		wantDisplay string
+	}{
+		{raw: "SWIGGY*ORDER 8821", wantKey: "swiggy", wantDisplay: "Swiggy"},
+		{raw: "swiggy@icici", wantKey: "swiggy", wantDisplay: "Swiggy"},
+		{raw: "ÉLAN CAFE", wantKey: "élan cafe", wantDisplay: "Élan Cafe"},
+		{raw: "9876543210@ybl", wantKey: "9876543210@ybl", wantDisplay: "9876543210@ybl"},
+		{raw: "  ", wantKey: "", wantDisplay: ""},
+	}
+	for _, tt := range tests {
+		key, display := NormalizeMerchant(tt.raw)
+		if key != tt.wantKey || display != tt.wantDisplay {
+			t.Errorf("NormalizeMerchant(%q) = %q, %q; want %q, %q", tt.raw, key, display, tt.wantKey, tt.wantDisplay)
+		}
+	}
+}
+
+func TestMerchantFromVPA(t *testing.T) {
+	tests := []struct {
+		vpa  string
+		want string
+	}{
+		{vpa: "swiggy.food@icici", want: "Swiggy Food"},
+		{vpa: "élan_store@okaxis", want: "Élan Store"},
+		{vpa: "9876543210@ybl", want: "9876543210@ybl"},
+	}
+	for _, tt := range tests {
+		if got := merchantFromVPA(tt.vpa); got != tt.want {
+			t.Errorf("merchantFromVPA(%q) = %q, want %q", tt.vpa, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ErrPatchNotObject is returned when a merge patch body isn't a JSON object
+var ErrPatchNotObject = errors.New("patch must be a JSON object")
+
+// MergePatch applies an RFC 7396 JSON merge patch to a decoded JSON value:
+// members set to null are removed, objects merge recursively and any other
+// value, arrays included, replaces the target outright
+func MergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = MergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// decodeJSON decodes keeping numbers exact, so amounts survive the round trip
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// ApplyMergePatch patches the writable fields of current with a merge patch
+// body and decodes the result into dest, ready to be validated and saved as a
+// full replacement. Fields not listed in fields are never read from current.
+// dependents maps a field to the ones derived from or overridden by it: when
+// the patch sets the field but not a dependent, the dependent is dropped so
+// it is re-derived, e.g. a new category name clears the old category_id.
+func ApplyMergePatch(current interface{}, body []byte, fields []string, dependents map[string][]string, dest interface{}) error {
+	var patch interface{}
+	if err := decodeJSON(body, &patch); err != nil {
+		return err
+	}
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return ErrPatchNotObject
+	}
+
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	var full map[string]interface{}
+	if err := decodeJSON(encoded, &full); err != nil {
+		return err
+	}
+	document := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := full[field]; ok {
+			document[field] = value
+		}
+	}
+
+	for field, derived := range dependents {
+		if _, ok := patchObject[field]; !ok {
+			continue
+		}
+		for _, dependent := range derived {
+			if _, ok := patchObject[dependent]; !ok {
+				delete(document, dependent)
+			}
+		}
+	}
+
+	merged, err := json.Marshal(MergePatch(document, patchObject))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(merged, dest)
+}
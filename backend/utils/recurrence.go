@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"errors"
+	"finance-app-backend/models"
+	"time"
+)
+
+// MaxScheduleFailures is how many runs in a row may fail to generate an
+// occurrence before the schedule is paused
+const MaxScheduleFailures = 5
+
+// ValidateSchedule checks a schedule's frequency, interval and bounds
+func ValidateSchedule(schedule models.Schedule) error {
+	switch schedule.Frequency {
+	case models.FrequencyDaily, models.FrequencyWeekly, models.FrequencyMonthly, models.FrequencyYearly:
+	default:
+		return errors.New("frequency must be daily, weekly, monthly or yearly")
+	}
+	if schedule.Interval < 1 {
+		return errors.New("interval must be at least 1")
+	}
+	if schedule.DayOfMonth < 0 || schedule.DayOfMonth > 31 {
+		return errors.New("day_of_month must be between 1 and 31")
+	}
+	if schedule.StartDate.IsZero() {
+		return errors.New("start_date is required")
+	}
+	if schedule.EndDate != nil && schedule.EndDate.Before(schedule.StartDate) {
+		return errors.New("end_date must be after start_date")
+	}
+	if schedule.MaxOccurrences < 0 {
+		return errors.New("max_occurrences cannot be negative")
+	}
+	return nil
+}
+
+// daysIn returns the number of days in a month
+func daysIn(year int, month time.Month, loc *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+}
+
+// ScheduleOccurrence returns the date of the nth (0-based) occurrence
+func ScheduleOccurrence(schedule models.Schedule, n int) time.Time {
+	start := schedule.StartDate
+	interval := schedule.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	switch schedule.Frequency {
+	case models.FrequencyDaily:
+		return start.AddDate(0, 0, n*interval)
+	case models.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*n*interval)
+	}
+
+	months := n * interval
+	if schedule.Frequency == models.FrequencyYearly {
+		months *= 12
+	}
+
+	day := schedule.DayOfMonth
+	if day == 0 {
+		day = start.Day()
+	}
+
+	// Normalize the target month first, then clamp the day to its length
+	target := time.Date(start.Year(), start.Month()+time.Month(months), 1,
+		start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+	if last := daysIn(target.Year(), target.Month(), target.Location()); day > last {
+		day = last
+	}
+	return time.Date(target.Year(), target.Month(), day,
+		start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+}
+
+// scheduleEnded reports whether the nth occurrence is past the schedule's limits
+func scheduleEnded(schedule models.Schedule, n int, occurrence time.Time) bool {
+	if schedule.MaxOccurrences > 0 && n >= schedule.MaxOccurrences {
+		return true
+	}
+	return schedule.EndDate != nil && occurrence.After(*schedule.EndDate)
+}
+
+// InitSchedule resets a schedule to its first occurrence on or after the start date
+func InitSchedule(schedule *models.Schedule) {
+	schedule.OccurrenceCount = 0
+	first := ScheduleOccurrence(*schedule, 0)
+
+	// A day_of_month earlier than the start day pushes the first run to the
+	// next period. The schedule is re-anchored there so the skipped date
+	// doesn't use up one of max_occurrences.
+	if first.Before(schedule.StartDate) {
+		schedule.StartDate = ScheduleOccurrence(*schedule, 1)
+		first = schedule.StartDate
+	}
+	setNextDue(schedule, first)
+}
+
+// AdvanceSchedule moves a schedule past its current occurrence
+func AdvanceSchedule(schedule *models.Schedule) {
+	schedule.OccurrenceCount++
+	setNextDue(schedule, ScheduleOccurrence(*schedule, schedule.OccurrenceCount))
+}
+
+// RefreshSchedule re-evaluates the current occurrence against the schedule's
+// limits after they were edited, ending or reopening the schedule
+func RefreshSchedule(schedule *models.Schedule) {
+	setNextDue(schedule, ScheduleOccurrence(*schedule, schedule.OccurrenceCount))
+}
+
+// RecordScheduleFailure notes a failed attempt to generate an occurrence and
+// pauses the schedule after MaxScheduleFailures in a row, so a rule whose
+// category or exchange rate is gone stops being retried. It reports whether
+// the schedule was paused.
+func RecordScheduleFailure(schedule *models.Schedule, err error) bool {
+	schedule.FailureCount++
+	schedule.LastError = err.Error()
+	if schedule.FailureCount >= MaxScheduleFailures {
+		schedule.IsPaused = true
+		return true
+	}
+	return false
+}
+
+// ClearScheduleFailures resets the failure count after a successful run or
+// when the user resumes the schedule
+func ClearScheduleFailures(schedule *models.Schedule) {
+	schedule.FailureCount = 0
+	schedule.LastError = ""
+}
+
+// setNextDue records the next occurrence, or clears it when the schedule has ended
+func setNextDue(schedule *models.Schedule, next time.Time) {
+	if scheduleEnded(*schedule, schedule.OccurrenceCount, next) {
+		schedule.NextDueAt = nil
+		return
+	}
+	schedule.NextDueAt = &next
+}
+
+// UpcomingOccurrences lists up to limit future occurrences due before `until`
+func UpcomingOccurrences(schedule models.Schedule, until time.Time, limit int) []time.Time {
+	var dates []time.Time
+	if schedule.NextDueAt == nil {
+		return dates
+	}
+
+	for n := schedule.OccurrenceCount; len(dates) < limit; n++ {
+		occurrence := ScheduleOccurrence(schedule, n)
+		if occurrence.After(until) || scheduleEnded(schedule, n, occurrence) {
+			break
+		}
+		dates = append(dates, occurrence)
+	}
+	return dates
+}
+
+// SkipOccurrencesUntil advances past every occurrence due at or before `until`
+// without generating anything. Used when resuming a paused schedule.
+func SkipOccurrencesUntil(schedule *models.Schedule, until time.Time) int {
+	skipped := 0
+	for schedule.NextDueAt != nil && !schedule.NextDueAt.After(until) {
+		AdvanceSchedule(schedule)
+		skipped++
+	}
+	return skipped
+}
+
+// RescheduleFrom re-anchors a schedule on a new start date after its pattern
+// changed. Occurrences already generated still count toward max_occurrences.
+func RescheduleFrom(schedule *models.Schedule, start time.Time) {
+	if schedule.MaxOccurrences > 0 {
+		remaining := schedule.MaxOccurrences - schedule.OccurrenceCount
+		if remaining <= 0 {
+			schedule.NextDueAt = nil
+			return
+		}
+		schedule.MaxOccurrences = remaining
+	}
+	schedule.StartDate = start
+	InitSchedule(schedule)
+}
@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"errors"
+	"finance-app-backend/models"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+// occurrences runs a schedule from InitSchedule the way the materializer
+// does, returning at most limit dates
+func occurrences(schedule models.Schedule, limit int) []time.Time {
+	InitSchedule(&schedule)
+	var dates []time.Time
+	for schedule.NextDueAt != nil && len(dates) < limit {
+		dates = append(dates, *schedule.NextDueAt)
+		AdvanceSchedule(&schedule)
+	}
+	return dates
+}
+
+func TestScheduleOccurrences(t *testing.T) {
+	endDate := date(2025, time.March, 15)
+	tests := []struct {
+		name      string
+		schedule  models.Schedule
+		want      []time.Time
+		unbounded bool // Only the first dates are checked
+	}{
+		{
+			name:      "monthly on the start day",
+			schedule:  models.Schedule{Frequency: models.FrequencyMonthly, Interval: 1, StartDate: date(2025, time.January, 10)},
+			want:      []time.Time{date(2025, time.January, 10), date(2025, time.February, 10), date(2025, time.March, 10)},
+			unbounded: true,
+		},
+		{
+			name:      "monthly on the 31st clamps to short months without drifting",
+			schedule:  models.Schedule{Frequency: models.FrequencyMonthly, Interval: 1, DayOfMonth: 31, StartDate: date(2024, time.January, 31)},
+			want:      []time.Time{date(2024, time.January, 31), date(2024, time.February, 29), date(2024, time.March, 31), date(2024, time.April, 30)},
+			unbounded: true,
+		},
+		{
+			name: "day before the start day begins next month and counts only real charges",
+			schedule: models.Schedule{Frequency: models.FrequencyMonthly, Interval: 1, DayOfMonth: 5,
+				StartDate: date(2025, time.October, 18), MaxOccurrences: 3},
+			want: []time.Time{date(2025, time.November, 5), date(2025, time.December, 5), date(2026, time.January, 5)},
+		},
+		{
+			name:      "weekly every two weeks",
+			schedule:  models.Schedule{Frequency: models.FrequencyWeekly, Interval: 2, StartDate: date(2025, time.January, 1)},
+			want:      []time.Time{date(2025, time.January, 1), date(2025, time.January, 15), date(2025, time.January, 29)},
+			unbounded: true,
+		},
+		{
+			name:     "daily stops at max occurrences",
+			schedule: models.Schedule{Frequency: models.FrequencyDaily, Interval: 1, StartDate: date(2025, time.January, 1), MaxOccurrences: 2},
+			want:     []time.Time{date(2025, time.January, 1), date(2025, time.January, 2)},
+		},
+		{
+			name:     "monthly stops after the end date",
+			schedule: models.Schedule{Frequency: models.FrequencyMonthly, Interval: 1, StartDate: date(2025, time.January, 20), EndDate: &endDate},
+			want:     []time.Time{date(2025, time.January, 20), date(2025, time.February, 20)},
+		},
+		{
+			name:      "yearly on 29 February",
+			schedule:  models.Schedule{Frequency: models.FrequencyYearly, Interval: 1, StartDate: date(2024, time.February, 29)},
+			want:      []time.Time{date(2024, time.February, 29), date(2025, time.February, 28), date(2026, time.February, 28), date(2027, time.February, 28), date(2028, time.February, 29)},
+			unbounded: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := len(tt.want) + 1
+			if tt.unbounded {
+				limit = len(tt.want)
+			}
+			got := occurrences(tt.schedule, limit)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d occurrences %v, want %d %v", len(got), got, len(tt.want), tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("occurrence %d = %s, want %s", i, got[i].Format("2006-01-02"), tt.want[i].Format("2006-01-02"))
+				}
+			}
+		})
+	}
+}
+
+func TestSkipOccurrencesUntil(t *testing.T) {
+	schedule := models.Schedule{Frequency: models.FrequencyMonthly, Interval: 1, StartDate: date(2025, time.January, 5)}
+	InitSchedule(&schedule)
+
+	skipped := SkipOccurrencesUntil(&schedule, date(2025, time.April, 1))
+	if skipped != 3 {
+		t.Errorf("skipped %d occurrences, want 3", skipped)
+	}
+	if want := date(2025, time.April, 5); schedule.NextDueAt == nil || !schedule.NextDueAt.Equal(want) {
+		t.Errorf("next due %v, want %s", schedule.NextDueAt, want.Format("2006-01-02"))
+	}
+}
+
+func TestRescheduleFromKeepsRemainingOccurrences(t *testing.T) {
+	schedule := models.Schedule{Frequency: models.FrequencyMonthly, Interval: 1, StartDate: date(2025, time.January, 1), MaxOccurrences: 4}
+	InitSchedule(&schedule)
+	AdvanceSchedule(&schedule)
+	AdvanceSchedule(&schedule)
+
+	schedule.Frequency = models.FrequencyWeekly
+	RescheduleFrom(&schedule, date(2025, time.March, 3))
+	got := []time.Time{}
+	for schedule.NextDueAt != nil && len(got) < 5 {
+		got = append(got, *schedule.NextDueAt)
+		AdvanceSchedule(&schedule)
+	}
+	want := []time.Time{date(2025, time.March, 3), date(2025, time.March, 10)}
+	if len(got) != len(want) || !got[0].Equal(want[0]) || !got[1].Equal(want[1]) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRefreshScheduleReopensAfterRaisingTheLimit(t *testing.T) {
+	schedule := models.Schedule{Frequency: models.FrequencyDaily, Interval: 1, StartDate: date(2025, time.January, 1), MaxOccurrences: 1}
+	InitSchedule(&schedule)
+	AdvanceSchedule(&schedule)
+	if schedule.NextDueAt != nil {
+		t.Fatalf("schedule should have ended, next due %v", schedule.NextDueAt)
+	}
+
+	schedule.MaxOccurrences = 2
+	RefreshSchedule(&schedule)
+	if want := date(2025, time.January, 2); schedule.NextDueAt == nil || !schedule.NextDueAt.Equal(want) {
+		t.Errorf("next due %v, want %s", schedule.NextDueAt, want.Format("2006-01-02"))
+	}
+}
+
+func TestRecordScheduleFailurePausesAfterRepeatedFailures(t *testing.T) {
+	var schedule models.Schedule
+	failure := errors.New("unknown category")
+	for i := 1; i < MaxScheduleFailures; i++ {
+		if RecordScheduleFailure(&schedule, failure) || schedule.IsPaused {
+			t.Fatalf("paused after %d failures, want %d", i, MaxScheduleFailures)
+		}
+	}
+	if !RecordScheduleFailure(&schedule, failure) || !schedule.IsPaused {
+		t.Errorf("not paused after %d failures", MaxScheduleFailures)
+	}
+	if schedule.LastError != failure.Error() {
+		t.Errorf("last error %q, want %q", schedule.LastError, failure.Error())
+	}
+
+	ClearScheduleFailures(&schedule)
+	if schedule.FailureCount != 0 || schedule.LastError != "" {
+		t.Errorf("failures not cleared: %d %q", schedule.FailureCount, schedule.LastError)
+	}
+}
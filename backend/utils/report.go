@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"finance-app-backend/models"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	reportMargin  = 40.0
+	reportWidth   = PDFPageWidth - 2*reportMargin
+	reportTop     = 50.0
+	reportBottom  = PDFPageHeight - 50.0
+	reportRowSize = 9.0
+	reportRowStep = 15.0
+)
+
+// reportColumn describes one column of a report table
+type reportColumn struct {
+	title string
+	width float64
+	right bool
+}
+
+// reportLayout tracks the write position while flowing a report across pages
+type reportLayout struct {
+	pdf *PDF
+	y   float64
+}
+
+func (l *reportLayout) newPage() {
+	l.pdf.AddPage()
+	l.y = reportTop
+}
+
+// ensure starts a new page unless height points still fit on this one
+func (l *reportLayout) ensure(height float64) bool {
+	if l.y+height > reportBottom {
+		l.newPage()
+		return true
+	}
+	return false
+}
+
+func (l *reportLayout) heading(title string) {
+	l.ensure(60)
+	l.y += 14
+	l.pdf.Text(reportMargin, l.y, 12, true, title)
+	l.y += 8
+}
+
+func (l *reportLayout) note(text string) {
+	l.ensure(reportRowStep)
+	l.y += reportRowStep
+	l.pdf.Text(reportMargin, l.y, reportRowSize, false, text)
+	l.y += 4
+}
+
+// cells draws one row of values; the caller positions l.y at the baseline
+func (l *reportLayout) cells(columns []reportColumn, values []string, bold bool) {
+	x := reportMargin
+	for i, column := range columns {
+		value := FitText(values[i], reportRowSize, column.width-6)
+		if column.right {
+			l.pdf.TextRight(x+column.width-3, l.y, reportRowSize, bold, value)
+		} else {
+			l.pdf.Text(x+3, l.y, reportRowSize, bold, value)
+		}
+		x += column.width
+	}
+}
+
+// table draws rows under a shaded header, repeating the header on each new page
+func (l *reportLayout) table(columns []reportColumn, rows [][]string) {
+	header := func() {
+		l.pdf.FillRect(reportMargin, l.y+4, reportWidth, reportRowStep, 0.90, 0.92, 0.95)
+		l.y += reportRowStep
+		titles := make([]string, len(columns))
+		for i, column := range columns {
+			titles[i] = column.title
+		}
+		l.cells(columns, titles, true)
+	}
+
+	l.ensure(reportRowStep * 3)
+	header()
+	for i, row := range rows {
+		if l.ensure(reportRowStep + 4) {
+			header()
+		}
+		if i%2 == 1 {
+			l.pdf.FillRect(reportMargin, l.y+4, reportWidth, reportRowStep, 0.97, 0.97, 0.97)
+		}
+		l.y += reportRowStep
+		l.cells(columns, row, false)
+	}
+	l.y += 4
+	l.pdf.Line(reportMargin, l.y, reportMargin+reportWidth, l.y, 0.5, 0.7)
+	l.y += 6
+}
+
+// FormatReportMoney formats an amount with digit grouping, using lakh/crore
+// grouping for rupees
+func FormatReportMoney(amount models.Money, currency string) string {
+	text := amount.String()
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+	whole, frac, _ := strings.Cut(text, ".")
+
+	var groups []string
+	if len(whole) > 3 {
+		groups = append(groups, whole[len(whole)-3:])
+		whole = whole[:len(whole)-3]
+		size := 3
+		if currency == "INR" {
+			size = 2
+		}
+		for len(whole) > size {
+			groups = append([]string{whole[len(whole)-size:]}, groups...)
+			whole = whole[:len(whole)-size]
+		}
+	}
+	groups = append([]string{whole}, groups...)
+
+	return fmt.Sprintf("%s %s%s.%s", currency, sign, strings.Join(groups, ","), frac)
+}
+
+func formatPercent(value float64) string {
+	return strconv.FormatFloat(value, 'f', 1, 64) + "%"
+}
+
+// WriteMonthlyReportPDF renders a monthly statement as a PDF document
+func WriteMonthlyReportPDF(w io.Writer, report models.MonthlyReport) error {
+	l := &reportLayout{pdf: NewPDF()}
+	l.newPage()
+
+	month, err := time.Parse("2006-01", report.Month)
+	if err != nil {
+		return err
+	}
+	currency := report.Currency
+	money := func(amount models.Money) string {
+		return FormatReportMoney(amount, currency)
+	}
+
+	// Title block
+	l.y += 10
+	l.pdf.Text(reportMargin, l.y, 18, true, "CapiFy Monthly Statement")
+	l.pdf.TextRight(reportMargin+reportWidth, l.y, 12, true, month.Format("January 2006"))
+	l.y += 16
+	l.pdf.Text(reportMargin, l.y, 10, false, report.UserName)
+	l.pdf.TextRight(reportMargin+reportWidth, l.y, 8, false,
+		"Generated "+report.GeneratedAt.Format("02 Jan 2006 15:04")+" - amounts in "+currency)
+	l.y += 10
+	l.pdf.Line(reportMargin, l.y, reportMargin+reportWidth, l.y, 1, 0.2)
+	l.y += 8
+
+	// Summary figures
+	summary := []struct{ label, value string }{
+		{"Income", money(report.Income)},
+		{"Spent", money(report.Expense)},
+		{"Net", money(report.Net)},
+		{"Savings rate", formatPercent(report.SavingsRate)},
+	}
+	boxWidth := reportWidth / float64(len(summary))
+	for i, item := range summary {
+		x := reportMargin + float64(i)*boxWidth
+		l.pdf.FillRect(x+2, l.y, boxWidth-4, 40, 0.95, 0.96, 0.98)
+		l.pdf.Text(x+10, l.y+14, 8, false, item.label)
+		l.pdf.Text(x+10, l.y+31, 12, true, FitText(item.value, 12, boxWidth-20))
+	}
+	l.y += 50
+
+	l.heading("Spending by category")
+	if len(report.Categories) == 0 {
+		l.note("No spending recorded this month.")
+	} else {
+		rows := make([][]string, len(report.Categories))
+		for i, category := range report.Categories {
+			name := category.Category
+			if name == "" {
+				name = "Uncategorized"
+			}
+			rows[i] = []string{name, strconv.Itoa(category.Count), formatPercent(category.Share), money(category.Total)}
+		}
+		rows = append(rows, []string{"Total", "", "", money(report.Expense)})
+		l.table([]reportColumn{
+			{title: "Category", width: 235},
+			{title: "Transactions", width: 80, right: true},
+			{title: "Share", width: 70, right: true},
+			{title: "Amount", width: 130, right: true},
+		}, rows)
+	}
+
+	l.heading("Budget vs actual")
+	if len(report.Budgets) == 0 {
+		l.note("No budgets cover this month.")
+	} else {
+		rows := make([][]string, len(report.Budgets))
+		for i, budget := range report.Budgets {
+			name := budget.Category
+			if budget.Tag != nil {
+				name = "#" + budget.Tag.Name
+			}
+			period := budget.StartDate.Format("02 Jan") + " - " + budget.EndDate.Format("02 Jan")
+			used := formatPercent(budget.Percentage)
+			if budget.Status != "safe" {
+				used += " " + strings.ToUpper(budget.Status[:1])
+			}
+			rows[i] = []string{name, period, money(budget.Amount), money(budget.CurrentSpent), money(budget.Remaining), used}
+		}
+		l.table([]reportColumn{
+			{title: "Budget", width: 115},
+			{title: "Period", width: 80},
+			{title: "Limit", width: 85, right: true},
+			{title: "Spent", width: 85, right: true},
+			{title: "Remaining", width: 85, right: true},
+			{title: "Used", width: 65, right: true},
+		}, rows)
+		l.note("W = over 75% of the limit, D = limit reached or exceeded.")
+	}
+
+	l.heading("Top merchants")
+	if len(report.Merchants) == 0 {
+		l.note("No merchants this month.")
+	} else {
+		rows := make([][]string, len(report.Merchants))
+		for i, merchant := range report.Merchants {
+			rows[i] = []string{merchant.Merchant, strconv.Itoa(merchant.Count), money(merchant.Total)}
+		}
+		l.table([]reportColumn{
+			{title: "Merchant", width: 305},
+			{title: "Transactions", width: 80, right: true},
+			{title: "Amount", width: 130, right: true},
+		}, rows)
+	}
+
+	l.heading("Transactions")
+	if len(report.Transactions) == 0 {
+		l.note("No transactions this month.")
+	} else {
+		rows := make([][]string, len(report.Transactions))
+		for i, expense := range report.Transactions {
+			original := ""
+			if expense.Currency != currency {
+				original = FormatReportMoney(expense.Amount, expense.Currency)
+			}
+			rows[i] = []string{expense.SpentAt.Format("02 Jan"), expense.Title, expense.Category, original, money(expense.BaseAmount)}
+		}
+		l.table([]reportColumn{
+			{title: "Date", width: 50},
+			{title: "Description", width: 175},
+			{title: "Category", width: 100},
+			{title: "Original", width: 85, right: true},
+			{title: "Amount", width: 105, right: true},
+		}, rows)
+	}
+
+	// Page footers go on last, once the page count is known
+	for i, page := range l.pdf.pages {
+		l.pdf.page = page
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(l.pdf.pages))
+		l.pdf.TextRight(reportMargin+reportWidth, PDFPageHeight-25, 8, false, footer)
+		l.pdf.Text(reportMargin, PDFPageHeight-25, 8, false, "CapiFy - "+month.Format("January 2006"))
+	}
+
+	_, err = l.pdf.WriteTo(w)
+	return err
+}
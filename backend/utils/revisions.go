@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"finance-app-backend/models"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ExpenseSnapshotOf captures the editable state of an expense loaded with its
+// Tags and Items
+func ExpenseSnapshotOf(expense models.Expense) models.ExpenseSnapshot {
+	tags := make([]string, len(expense.Tags))
+	for i, tag := range expense.Tags {
+		tags[i] = tag.Name
+	}
+	sort.Strings(tags)
+
+	items := make([]models.SnapshotItem, len(expense.Items))
+	for i, item := range expense.Items {
+		items[i] = models.SnapshotItem{
+			Title:      item.Title,
+			Amount:     item.Amount,
+			CategoryID: item.CategoryID,
+			Category:   item.Category,
+		}
+	}
+
+	return models.ExpenseSnapshot{
+		Title:        expense.Title,
+		Amount:       expense.Amount,
+		Currency:     expense.Currency,
+		CategoryID:   expense.CategoryID,
+		Category:     expense.Category,
+		Description:  expense.Description,
+		SpentAt:      expense.SpentAt.UTC(),
+		AccountID:    expense.AccountID,
+		MerchantID:   expense.MerchantID,
+		Latitude:     expense.Latitude,
+		Longitude:    expense.Longitude,
+		PlaceName:    expense.PlaceName,
+		BaseAmount:   expense.BaseAmount,
+		BaseCurrency: expense.BaseCurrency,
+		ExchangeRate: expense.ExchangeRate,
+		Reimbursable: expense.Reimbursable,
+		TaxSection:   expense.TaxSection,
+		Tags:         tags,
+		Items:        items,
+	}
+}
+
+// snapshotValue unwraps pointers so a change reads "from": 3 rather than an address
+func snapshotValue(value reflect.Value) interface{} {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		return value.Elem().Interface()
+	}
+	if value.Kind() == reflect.Slice && value.Len() == 0 {
+		return nil
+	}
+	return value.Interface()
+}
+
+// DiffExpenseSnapshots lists the fields that differ between two snapshots,
+// named by their JSON keys, in declaration order
+func DiffExpenseSnapshots(before, after models.ExpenseSnapshot) []models.FieldChange {
+	changes := []models.FieldChange{}
+	beforeValue, afterValue := reflect.ValueOf(before), reflect.ValueOf(after)
+	snapshotType := beforeValue.Type()
+	for i := 0; i < snapshotType.NumField(); i++ {
+		from, to := snapshotValue(beforeValue.Field(i)), snapshotValue(afterValue.Field(i))
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+		field := strings.Split(snapshotType.Field(i).Tag.Get("json"), ",")[0]
+		changes = append(changes, models.FieldChange{Field: field, From: from, To: to})
+	}
+	return changes
+}
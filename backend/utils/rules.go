@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"errors"
+	"finance-app-backend/models"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// maxRulePatternLength keeps user-supplied regexps small
+const maxRulePatternLength = 200
+
+// rulePatterns caches compiled rule regexps by source
+var rulePatterns sync.Map
+
+func compileRulePattern(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := rulePatterns.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	compiled, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, err
+	}
+	rulePatterns.Store(pattern, compiled)
+	return compiled, nil
+}
+
+// foldText lower-cases and collapses whitespace for contains matching
+func foldText(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// ValidateRule checks that a rule has at least one condition and one action
+func ValidateRule(rule models.CategoryRule) error {
+	if strings.TrimSpace(rule.TitleContains) == "" && strings.TrimSpace(rule.DescriptionContains) == "" &&
+		rule.Pattern == "" && rule.MinAmount == nil && rule.MaxAmount == nil && rule.AccountID == nil {
+		return errors.New("rule needs at least one condition")
+	}
+	if rule.CategoryID == nil && strings.TrimSpace(rule.Category) == "" && len(rule.AddTags) == 0 {
+		return errors.New("rule must set a category or add tags")
+	}
+	if rule.Pattern != "" {
+		if len(rule.Pattern) > maxRulePatternLength {
+			return errors.New("pattern is too long")
+		}
+		if _, err := compileRulePattern(rule.Pattern); err != nil {
+			return errors.New("invalid pattern: " + err.Error())
+		}
+	}
+	if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+		return errors.New("min_amount must not exceed max_amount")
+	}
+	return nil
+}
+
+// RuleMatches reports whether an expense satisfies every condition of a rule.
+// Amounts are compared in the base currency, so convert the expense first.
+func RuleMatches(rule models.CategoryRule, expense models.Expense) bool {
+	title := foldText(expense.Title)
+	description := foldText(expense.Description)
+
+	if needle := foldText(rule.TitleContains); needle != "" && !strings.Contains(title, needle) {
+		return false
+	}
+	if needle := foldText(rule.DescriptionContains); needle != "" && !strings.Contains(description, needle) {
+		return false
+	}
+	if rule.Pattern != "" {
+		compiled, err := compileRulePattern(rule.Pattern)
+		if err != nil || !compiled.MatchString(expense.Title+"\n"+expense.Description) {
+			return false
+		}
+	}
+	if rule.MinAmount != nil && expense.BaseAmount < *rule.MinAmount {
+		return false
+	}
+	if rule.MaxAmount != nil && expense.BaseAmount > *rule.MaxAmount {
+		return false
+	}
+	if rule.AccountID != nil && (expense.AccountID == nil || *expense.AccountID != *rule.AccountID) {
+		return false
+	}
+	return true
+}
+
+// SortRules orders rules for evaluation: the user's own rules before global
+// ones, then by descending priority, then oldest first
+func SortRules(rules []models.CategoryRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].IsGlobal() != rules[j].IsGlobal() {
+			return !rules[i].IsGlobal()
+		}
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		return rules[i].ID < rules[j].ID
+	})
+}
+
+// LoadRules fetches the active rules that apply to a user, in evaluation order
+func LoadRules(db *gorm.DB, userID uint) ([]models.CategoryRule, error) {
+	var rules []models.CategoryRule
+	if err := db.Where("is_active = ? AND (user_id = ? OR user_id IS NULL)", true, userID).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	SortRules(rules)
+	return rules, nil
+}
+
+// MerchantKey reduces an expense title to a stable payee key for learning,
+// dropping digits and punctuation: "Swiggy Order #4821" -> "swiggy order"
+func MerchantKey(title string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, title)
+
+	words := strings.Fields(cleaned)
+	if len(words) > 2 {
+		words = words[:2]
+	}
+	return strings.Join(words, " ")
+}
+
+// EnsureDefaultRules seeds the global rules the first time the app starts.
+// Global rules deleted by an operator are not recreated.
+func EnsureDefaultRules(db *gorm.DB) error {
+	var count int64
+	if err := db.Unscoped().Model(&models.CategoryRule{}).Where("user_id IS NULL").Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	rules := make([]models.CategoryRule, len(models.DefaultCategoryRules))
+	for i, rule := range models.DefaultCategoryRules {
+		rule.IsActive = true
+		rules[i] = rule
+	}
+	return db.Create(&rules).Error
+}
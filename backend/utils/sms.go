@@ -0,0 +1,362 @@
+package utils
+
+import (
+	"errors"
+	"finance-app-backend/models"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Bank SMS formats change often. Keep rules here, most specific first, and add
+// a sample message to the comment of every template you add.
+
+// smsBank identifies a bank from the sender ID or the message text
+type smsBank struct {
+	Name     string
+	Senders  []string // Fragments of the DLT sender ID, e.g. "HDFCBK" in "VM-HDFCBK"
+	Keywords []string // Case-insensitive phrases in the body
+}
+
+var smsBanks = []smsBank{
+	{Name: "HDFC Bank", Senders: []string{"HDFCBK", "HDFCBN"}, Keywords: []string{"hdfc bank"}},
+	{Name: "ICICI Bank", Senders: []string{"ICICIB", "ICICIT"}, Keywords: []string{"icici bank"}},
+	{Name: "State Bank of India", Senders: []string{"SBIINB", "SBIUPI", "SBMSMS", "ATMSBI", "CBSSBI"}, Keywords: []string{"-sbi", "sbi "}},
+	{Name: "Axis Bank", Senders: []string{"AXISBK", "AXISMR"}, Keywords: []string{"axis bank"}},
+	{Name: "Kotak Mahindra Bank", Senders: []string{"KOTAKB", "KMBANK"}, Keywords: []string{"kotak"}},
+	{Name: "Punjab National Bank", Senders: []string{"PNBSMS", "PUNBNK"}, Keywords: []string{"pnb"}},
+	{Name: "Bank of Baroda", Senders: []string{"BOBTXN", "BOBSMS"}, Keywords: []string{"bank of baroda", "bob "}},
+	{Name: "Yes Bank", Senders: []string{"YESBNK"}, Keywords: []string{"yes bank"}},
+	{Name: "IDFC FIRST Bank", Senders: []string{"IDFCFB"}, Keywords: []string{"idfc first"}},
+	{Name: "IndusInd Bank", Senders: []string{"INDUSB"}, Keywords: []string{"indusind"}},
+}
+
+// smsTemplate is a full-message pattern for a known format. Named groups:
+// amount, direction, account, merchant, vpa, date.
+type smsTemplate struct {
+	Name      string
+	Bank      string // Empty for formats shared by many banks
+	Direction string // Fixed direction when the pattern has no direction group
+	Pattern   *regexp.Regexp
+}
+
+const (
+	smsAmount  = `(?:rs\.?|inr|₹)\s*(?P<amount>[\d,]+(?:\.\d{1,2})?)`
+	smsAccount = `[x*.]*(?P<account>\d{3,6})`
+	smsDate    = `(?P<date>\d{4}-\d{2}-\d{2}|\d{1,2}[-/ ]?(?:\d{1,2}|[a-z]{3})[-/ ]?\d{2,4})`
+	smsVPA     = `(?P<vpa>[\w.\-]+@[a-z]+)`
+)
+
+var smsTemplates = []smsTemplate{
+	{
+		// Rs.450.00 debited from A/c XX1234 to VPA swiggy@icici on 12-10-25
+		Name: "upi_vpa",
+		Pattern: regexp.MustCompile(`(?i)` + smsAmount + `\s+(?:has been\s+|is\s+)?(?P<direction>debited|credited)\s+(?:from|to)\s+(?:your\s+)?a/?c\s*(?:no\.?\s*)?` +
+			smsAccount + `\s+(?:to|by|from)\s+(?:vpa\s+)?` + smsVPA + `\s+on\s+` + smsDate),
+	},
+	{
+		// Spent Rs.1,250.00 On HDFC Bank Card 1234 At AMAZON On 2025-10-12:14:22:11
+		Name: "hdfc_card", Bank: "HDFC Bank", Direction: models.DirectionDebit,
+		Pattern: regexp.MustCompile(`(?i)spent\s+` + smsAmount + `\s+on\s+hdfc bank card\s+` + smsAccount +
+			`\s+at\s+(?P<merchant>.+?)\s+on\s+` + smsDate),
+	},
+	{
+		// INR 2,340.00 spent using ICICI Bank Card XX1234 on 12-Oct-25 on AMAZON PAY. Avl Limit: INR 50,000.00
+		Name: "icici_card", Bank: "ICICI Bank", Direction: models.DirectionDebit,
+		Pattern: regexp.MustCompile(`(?i)` + smsAmount + `\s+spent\s+(?:using|on)\s+icici bank card\s+` + smsAccount +
+			`\s+on\s+` + smsDate + `\s+(?:on|at)\s+(?P<merchant>[^.]+)`),
+	},
+	{
+		// Dear UPI user A/C X1234 debited by 450.0 on date 12Oct25 trf to SWIGGY Refno 123456. -SBI
+		Name: "sbi_upi", Bank: "State Bank of India",
+		Pattern: regexp.MustCompile(`(?i)a/c\s+` + smsAccount + `\s+(?P<direction>debited|credited)\s+by\s+(?P<amount>[\d,]+(?:\.\d{1,2})?)\s+on\s+date\s+` +
+			smsDate + `\s+(?:trf to|transfer from)\s+(?P<merchant>.+?)\s+ref\s*no`),
+	},
+	{
+		// INR 500.00 debited A/c no. XX1234 12-10-25, 14:22:11 UPI/P2M/123456/SWIGGY Not you? SMS BLOCK
+		Name: "axis_upi", Bank: "Axis Bank",
+		Pattern: regexp.MustCompile(`(?i)` + smsAmount + `\s+(?P<direction>debited|credited)\s+a/c no\.?\s+` + smsAccount +
+			`\s+` + smsDate + `,?\s+[\d:]+\s+upi/p2[am]/\d+/(?P<merchant>[^\s/]+)`),
+	},
+	{
+		// Sent Rs.450.00 from Kotak Bank AC X1234 to swiggy@icici on 12-10-25.UPI Ref 123456
+		Name: "kotak_upi", Bank: "Kotak Mahindra Bank", Direction: models.DirectionDebit,
+		Pattern: regexp.MustCompile(`(?i)sent\s+` + smsAmount + `\s+from\s+kotak bank a/?c\s+` + smsAccount +
+			`\s+to\s+` + smsVPA + `\s+on\s+` + smsDate),
+	},
+	{
+		// Received Rs.1,200.00 in your Kotak Bank AC X1234 from rahul@okaxis on 12-10-25.UPI Ref:123456
+		Name: "kotak_upi_credit", Bank: "Kotak Mahindra Bank", Direction: models.DirectionCredit,
+		Pattern: regexp.MustCompile(`(?i)received\s+` + smsAmount + `\s+in\s+your\s+kotak bank a/?c\s+` + smsAccount +
+			`\s+from\s+` + smsVPA + `\s+on\s+` + smsDate),
+	},
+	{
+		// Rs.50,000.00 credited to A/c XX1234 on 01-10-25 by NEFT from ACME CORP PVT LTD
+		Name: "neft_credit", Direction: models.DirectionCredit,
+		Pattern: regexp.MustCompile(`(?i)` + smsAmount + `\s+(?:has been\s+)?credited\s+to\s+(?:your\s+)?a/?c\s*(?:no\.?\s*)?` + smsAccount +
+			`\s+on\s+` + smsDate + `\s+by\s+(?:neft|imps|rtgs)\s+from\s+(?P<merchant>[^.]+)`),
+	},
+}
+
+// Generic extractors used when no template matches
+var (
+	smsAmountRe    = regexp.MustCompile(`(?i)` + smsAmount)
+	smsAmountByRe  = regexp.MustCompile(`(?i)(?:debited|credited)\s+(?:by|with)\s+(?P<amount>[\d,]+(?:\.\d{1,2})?)`)
+	smsAccountRe   = regexp.MustCompile(`(?i)(?:a/?c|acct|account|card)\s*(?:no\.?)?\s*(?:ending\s*(?:with|in)?\s*)?` + smsAccount)
+	smsVPARe       = regexp.MustCompile(`(?i)` + smsVPA)
+	smsDateRe      = regexp.MustCompile(`(?i)\b` + smsDate + `\b`)
+	smsMerchantRe  = regexp.MustCompile(`(?i)\b(?:at|to|towards|trf to|info:?)\s+(?P<merchant>[a-z0-9&*.' \-]{2,40}?)(?:\s+on\b|\s+ref|\s+upi|\.\s|,|$)`)
+	smsDebitWords  = []string{"debited", "spent", "withdrawn", "sent", "paid", "purchase", "deducted"}
+	smsCreditWords = []string{"credited", "received", "deposited", "refund", "reversed"}
+	smsIgnoreWords = []string{"otp", "one time password", "will be debited", "is due", "due on", "requested", "collect request", "emi of"}
+)
+
+var smsDateLayouts = []string{
+	"02-01-06", "02-01-2006", "02/01/06", "02/01/2006",
+	"02-Jan-06", "02-Jan-2006", "02Jan06", "02Jan2006", "02 Jan 2006", "02 Jan 06",
+	"2-1-06", "2-1-2006", "2/1/06", "2/1/2006",
+	"2006-01-02",
+}
+
+// ErrNotTransaction is returned for OTPs, reminders and other non-transaction SMS
+var ErrNotTransaction = errors.New("message is not a completed transaction")
+
+// parseSMSDate reads the day-first dates Indian banks use
+func parseSMSDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range smsDateLayouts {
+		// Month names match case-insensitively, so "12OCT25" parses too
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// detectSMSBank finds the bank from the sender ID, then the message body
+func detectSMSBank(sender, text string) string {
+	sender = strings.ToUpper(sender)
+	lower := strings.ToLower(text)
+	for _, bank := range smsBanks {
+		for _, fragment := range bank.Senders {
+			if sender != "" && strings.Contains(sender, fragment) {
+				return bank.Name
+			}
+		}
+	}
+	for _, bank := range smsBanks {
+		for _, keyword := range bank.Keywords {
+			if strings.Contains(lower, keyword) {
+				return bank.Name
+			}
+		}
+	}
+	return ""
+}
+
+// merchantFromVPA turns "swiggy.food@icici" into "Swiggy Food". Phone-number
+// handles are left as the VPA since they name a person, not a merchant.
+func merchantFromVPA(vpa string) string {
+	if isPhoneVPA(vpa) {
+		return vpa
+	}
+	handle := strings.SplitN(vpa, "@", 2)[0]
+	words := strings.FieldsFunc(handle, func(r rune) bool {
+		return r == '.' || r == '-' || r == '_' || (r >= '0' && r <= '9')
+	})
+	for i, word := range words {
+		words[i] = capitalize(strings.ToLower(word))
+	}
+	return strings.Join(words, " ")
+}
+
+// TruncateRunes shortens s to at most n characters without splitting a
+// multibyte character
+func TruncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// cleanMerchant tidies a captured merchant name
+func cleanMerchant(value string) string {
+	value = strings.Trim(strings.Join(strings.Fields(value), " "), " .,:-*")
+	return TruncateRunes(value, 60)
+}
+
+// directionFromWords infers debit/credit from verbs in the message
+func directionFromWords(lower string) string {
+	debitAt, creditAt := -1, -1
+	for _, word := range smsDebitWords {
+		if i := strings.Index(lower, word); i >= 0 && (debitAt < 0 || i < debitAt) {
+			debitAt = i
+		}
+	}
+	for _, word := range smsCreditWords {
+		if i := strings.Index(lower, word); i >= 0 && (creditAt < 0 || i < creditAt) {
+			creditAt = i
+		}
+	}
+	// "debited ... credited to beneficiary" is a debit: the first verb wins
+	switch {
+	case debitAt >= 0 && (creditAt < 0 || debitAt < creditAt):
+		return models.DirectionDebit
+	case creditAt >= 0:
+		return models.DirectionCredit
+	}
+	return ""
+}
+
+// namedGroups returns a regexp match as a map of group name to value
+func namedGroups(re *regexp.Regexp, text string) map[string]string {
+	match := re.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+	groups := make(map[string]string)
+	for i, name := range re.SubexpNames() {
+		if name != "" && match[i] != "" {
+			groups[name] = match[i]
+		}
+	}
+	return groups
+}
+
+// ParseSMS extracts a draft transaction from a bank or UPI SMS. Known formats
+// score highly; anything else falls back to generic extraction with a
+// confidence reflecting how many fields were found.
+func ParseSMS(message models.SMSMessage) (models.SMSDraft, error) {
+	text := strings.Join(strings.Fields(message.Text), " ")
+	lower := strings.ToLower(text)
+
+	for _, word := range smsIgnoreWords {
+		if strings.Contains(lower, word) {
+			return models.SMSDraft{}, ErrNotTransaction
+		}
+	}
+
+	draft := models.SMSDraft{Currency: models.DefaultCurrency, Bank: detectSMSBank(message.Sender, text)}
+
+	var fields map[string]string
+	for _, template := range smsTemplates {
+		if fields = namedGroups(template.Pattern, text); fields != nil {
+			draft.Rule = template.Name
+			if template.Bank != "" {
+				draft.Bank = template.Bank
+			}
+			if fields["direction"] == "" {
+				fields["direction"] = template.Direction
+			}
+			break
+		}
+	}
+
+	if fields == nil {
+		draft.Rule = "generic"
+		fields = make(map[string]string)
+		if groups := namedGroups(smsAmountRe, text); groups != nil {
+			fields["amount"] = groups["amount"]
+		} else if groups := namedGroups(smsAmountByRe, text); groups != nil {
+			fields["amount"] = groups["amount"]
+		}
+		if groups := namedGroups(smsAccountRe, text); groups != nil {
+			fields["account"] = groups["account"]
+		}
+		if groups := namedGroups(smsVPARe, text); groups != nil {
+			fields["vpa"] = groups["vpa"]
+		}
+		if groups := namedGroups(smsDateRe, text); groups != nil {
+			fields["date"] = groups["date"]
+		}
+		if groups := namedGroups(smsMerchantRe, text); groups != nil {
+			fields["merchant"] = groups["merchant"]
+		}
+	}
+
+	if fields["amount"] == "" {
+		return models.SMSDraft{}, errors.New("no amount found")
+	}
+	amount, err := models.ParseMoney(strings.ReplaceAll(fields["amount"], ",", ""))
+	if err != nil || amount <= 0 {
+		return models.SMSDraft{}, errors.New("invalid amount")
+	}
+	draft.Amount = amount
+
+	draft.Direction = strings.ToLower(fields["direction"])
+	switch draft.Direction {
+	case "debited":
+		draft.Direction = models.DirectionDebit
+	case "credited":
+		draft.Direction = models.DirectionCredit
+	case "":
+		draft.Direction = directionFromWords(lower)
+	}
+
+	draft.AccountSuffix = fields["account"]
+	draft.VPA = strings.ToLower(fields["vpa"])
+	draft.Merchant = cleanMerchant(fields["merchant"])
+	if draft.Merchant == "" && draft.VPA != "" {
+		draft.Merchant = merchantFromVPA(draft.VPA)
+	}
+
+	dateFound := false
+	if fields["date"] != "" {
+		draft.Date, dateFound = parseSMSDate(fields["date"])
+	}
+	if !dateFound {
+		if message.ReceivedAt != nil {
+			draft.Date = *message.ReceivedAt
+		} else {
+			draft.Date = time.Now()
+		}
+	}
+
+	if draft.Rule == "generic" {
+		// Weight each field by how much it tells us about the transaction
+		score := 0.35
+		if draft.Direction != "" {
+			score += 0.2
+		}
+		if draft.AccountSuffix != "" {
+			score += 0.1
+		}
+		if draft.Merchant != "" {
+			score += 0.15
+		}
+		if dateFound {
+			score += 0.1
+		}
+		if draft.Bank != "" {
+			score += 0.05
+		}
+		// Never rank a generic guess above a known format
+		draft.Confidence = math.Round(math.Min(score, 0.8)*100) / 100
+	} else {
+		draft.Confidence = 0.9
+		if draft.Bank != "" {
+			draft.Confidence = 0.95
+		}
+	}
+
+	if draft.Direction == "" {
+		draft.Direction = models.DirectionDebit
+	}
+	draft.Kind = "expense"
+	if draft.Direction == models.DirectionCredit {
+		draft.Kind = "income"
+	}
+
+	draft.Title = draft.Merchant
+	if draft.Title == "" {
+		if draft.Kind == "income" {
+			draft.Title = "Bank credit"
+		} else {
+			draft.Title = "Bank debit"
+		}
+	}
+
+	return draft, nil
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"errors"
+	"finance-app-backend/models"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestParseSMS(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		sender    string
+		rule      string
+		bank      string
+		direction string
+		amount    models.Money
+		account   string
+		merchant  string
+		date      string
+	}{
+		{
+			name: "upi debit to a vpa",
+			text: "Rs.450.00 debited from A/c XX1234 to VPA swiggy.food@icici on 12-10-25",
+			rule: "upi_vpa", direction: models.DirectionDebit, amount: 45000,
+			account: "1234", merchant: "Swiggy Food", date: "2025-10-12",
+		},
+		{
+			name: "hdfc card spend",
+			text: "Spent Rs.1,250.00 On HDFC Bank Card 1234 At AMAZON On 2025-10-12:14:22:11",
+			rule: "hdfc_card", bank: "HDFC Bank", direction: models.DirectionDebit, amount: 125000,
+			account: "1234", merchant: "AMAZON", date: "2025-10-12",
+		},
+		{
+			name: "icici card spend",
+			text: "INR 2,340.00 spent using ICICI Bank Card XX1234 on 12-Oct-25 on AMAZON PAY. Avl Limit: INR 50,000.00",
+			rule: "icici_card", bank: "ICICI Bank", direction: models.DirectionDebit, amount: 234000,
+			account: "1234", merchant: "AMAZON PAY", date: "2025-10-12",
+		},
+		{
+			name: "kotak upi credit",
+			text: "Received Rs.1,200.00 in your Kotak Bank AC X1234 from rahul@okaxis on 12-10-25.UPI Ref:123456",
+			rule: "kotak_upi_credit", bank: "Kotak Mahindra Bank", direction: models.DirectionCredit, amount: 120000,
+			account: "1234", date: "2025-10-12",
+		},
+		{
+			name: "neft salary credit",
+			text: "Rs.50,000.00 credited to A/c XX1234 on 01-10-25 by NEFT from ACME CORP PVT LTD",
+			rule: "neft_credit", direction: models.DirectionCredit, amount: 5000000,
+			account: "1234", merchant: "ACME CORP PVT LTD", date: "2025-10-01",
+		},
+		{
+			name: "unknown format falls back to generic extraction",
+			text: "Your a/c no. XX5678 is debited with INR 99.50 at CAFE COFFEE DAY on 03/09/2025.",
+			rule: "generic", direction: models.DirectionDebit, amount: 9950,
+			account: "5678", merchant: "CAFE COFFEE DAY", date: "2025-09-03",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			draft, err := ParseSMS(models.SMSMessage{Text: tt.text, Sender: tt.sender})
+			if err != nil {
+				t.Fatalf("ParseSMS returned error %v", err)
+			}
+			if draft.Rule != tt.rule {
+				t.Errorf("rule = %q, want %q", draft.Rule, tt.rule)
+			}
+			if tt.bank != "" && draft.Bank != tt.bank {
+				t.Errorf("bank = %q, want %q", draft.Bank, tt.bank)
+			}
+			if draft.Direction != tt.direction {
+				t.Errorf("direction = %q, want %q", draft.Direction, tt.direction)
+			}
+			if draft.Amount != tt.amount {
+				t.Errorf("amount = %s, want %s", draft.Amount, tt.amount)
+			}
+			if draft.AccountSuffix != tt.account {
+				t.Errorf("account = %q, want %q", draft.AccountSuffix, tt.account)
+			}
+			if tt.merchant != "" && draft.Merchant != tt.merchant {
+				t.Errorf("merchant = %q, want %q", draft.Merchant, tt.merchant)
+			}
+			if got := draft.Date.Format("2006-01-02"); got != tt.date {
+				t.Errorf("date = %s, want %s", got, tt.date)
+			}
+		})
+	}
+}
+
+func TestParseSMSRejectsNonTransactions(t *testing.T) {
+	for _, text := range []string{
+		"123456 is your OTP for a transaction of Rs.500.00 at AMAZON. Do not share it.",
+		"Rs.2,000.00 will be debited from A/c XX1234 on 05-11-25 towards your SIP.",
+		"Your credit card bill of Rs.5,000.00 is due on 10-11-25.",
+	} {
+		if _, err := ParseSMS(models.SMSMessage{Text: text}); !errors.Is(err, ErrNotTransaction) {
+			t.Errorf("ParseSMS(%q) error = %v, want ErrNotTransaction", text, err)
+		}
+	}
+	if _, err := ParseSMS(models.SMSMessage{Text: "Your account statement is ready."}); err == nil {
+		t.Error("ParseSMS accepted a message without an amount")
+	}
+}
+
+func TestParseSMSFallsBackToReceivedAt(t *testing.T) {
+	received := time.Date(2025, time.October, 12, 18, 30, 0, 0, time.Local)
+	draft, err := ParseSMS(models.SMSMessage{Text: "Rs.120.00 paid to PHARMACY", ReceivedAt: &received})
+	if err != nil {
+		t.Fatalf("ParseSMS returned error %v", err)
+	}
+	if !draft.Date.Equal(received) {
+		t.Errorf("date = %v, want %v", draft.Date, received)
+	}
+}
+
+func TestCleanMerchantTruncatesByCharacter(t *testing.T) {
+	name := strings.Repeat("é", 59) + "€uro"
+	got := cleanMerchant(name)
+	if !utf8.ValidString(got) {
+		t.Fatalf("cleanMerchant returned invalid UTF-8 %q", got)
+	}
+	if n := utf8.RuneCountInString(got); n != 60 {
+		t.Errorf("cleanMerchant kept %d characters, want 60", n)
+	}
+	if got := cleanMerchant("  Big   Bazaar. "); got != "Big Bazaar" {
+		t.Errorf("cleanMerchant tidied to %q, want %q", got, "Big Bazaar")
+	}
+}
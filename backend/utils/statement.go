@@ -0,0 +1,408 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"finance-app-backend/models"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Number formats found in bank statements
+const (
+	NumberFormatIndian        = "indian"        // 1,00,000.00
+	NumberFormatInternational = "international" // 100,000.00
+	NumberFormatEuropean      = "european"      // 100.000,00
+	NumberFormatPlain         = "plain"         // 100000.00
+)
+
+var (
+	indianGroupingRe   = regexp.MustCompile(`^\d{1,2}(,\d{2})+,\d{3}(\.\d+)?$`)
+	westernGroupingRe  = regexp.MustCompile(`^\d{1,3}(,\d{3})+(\.\d+)?$`)
+	europeanGroupingRe = regexp.MustCompile(`^\d{1,3}(\.\d{3})*,\d{1,2}$`)
+)
+
+// statementDateLayouts are tried in order; day-first layouts come before the
+// US month-first one because Indian banks write 05/10/2025 for 5 October
+var statementDateLayouts = []string{
+	"02/01/2006", "02-01-2006", "02.01.2006",
+	"02/01/06", "02-01-06", "02.01.06",
+	"02-Jan-2006", "02 Jan 2006", "02-Jan-06", "02 Jan 06", "02/Jan/2006",
+	"2006-01-02", "2006/01/02",
+	"2/1/2006", "2-1-2006",
+	"02/01/2006 15:04:05", "02-01-2006 15:04:05", "2006-01-02 15:04:05",
+	"01/02/2006",
+}
+
+// stripAmount removes currency markers, whitespace and Cr/Dr suffixes,
+// returning the bare number and whether it was marked negative
+func stripAmount(raw string) (string, bool) {
+	value := strings.TrimSpace(raw)
+	negative := false
+
+	// "1,250.00 Dr" / "1,250.00 Cr." mark direction with a suffix
+	trimmed := strings.TrimRight(strings.ToLower(value), ". ")
+	if strings.HasSuffix(trimmed, "dr") {
+		negative = true
+		value = value[:len(trimmed)-2]
+	} else if strings.HasSuffix(trimmed, "cr") {
+		value = value[:len(trimmed)-2]
+	}
+
+	for _, marker := range []string{"₹", "INR", "Rs.", "Rs", "rs.", "rs"} {
+		value = strings.ReplaceAll(value, marker, "")
+	}
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = value[1 : len(value)-1]
+	}
+	if strings.HasPrefix(value, "-") {
+		negative = !negative
+		value = value[1:]
+	} else if strings.HasSuffix(value, "-") {
+		negative = !negative
+		value = value[:len(value)-1]
+	}
+	value = strings.TrimPrefix(value, "+")
+	return value, negative
+}
+
+// DetectNumberFormat guesses how amounts are grouped from sample values
+func DetectNumberFormat(samples []string) string {
+	format := NumberFormatPlain
+	for _, sample := range samples {
+		value, _ := stripAmount(sample)
+		switch {
+		case indianGroupingRe.MatchString(value):
+			return NumberFormatIndian
+		case europeanGroupingRe.MatchString(value) && strings.Contains(value, ","):
+			format = NumberFormatEuropean
+		case westernGroupingRe.MatchString(value) && format == NumberFormatPlain:
+			format = NumberFormatInternational
+		}
+	}
+	return format
+}
+
+// ParseStatementAmount parses an amount in the given number format. Empty
+// cells return zero without error since debit/credit columns are often blank.
+func ParseStatementAmount(raw, format string) (models.Money, error) {
+	value, negative := stripAmount(raw)
+	if value == "" || value == "-" {
+		return 0, nil
+	}
+
+	if format == NumberFormatEuropean {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	amount, err := models.ParseMoney(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", strings.TrimSpace(raw))
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// DetectDateFormat returns the first layout that parses every sample
+func DetectDateFormat(samples []string) (string, error) {
+	for _, layout := range statementDateLayouts {
+		matched := 0
+		for _, sample := range samples {
+			sample = strings.TrimSpace(sample)
+			if sample == "" {
+				continue
+			}
+			if _, err := time.ParseInLocation(layout, sample, time.Local); err != nil {
+				matched = -1
+				break
+			}
+			matched++
+		}
+		if matched > 0 {
+			return layout, nil
+		}
+	}
+	return "", errors.New("could not detect the date format; set date_format on the mapping")
+}
+
+// ReadStatementCSV reads a CSV export, detecting a semicolon or tab delimiter
+// and tolerating ragged rows and a UTF-8 byte order mark. Blank lines come
+// back as empty records.
+func ReadStatementCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	switch {
+	case bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")):
+		reader.Comma = ';'
+	case bytes.Count(firstLine, []byte("\t")) > bytes.Count(firstLine, []byte(",")):
+		reader.Comma = '\t'
+	}
+
+	// The reader skips blank lines; pad them back in so a record's index is
+	// still its line in the file and row numbers point at the right line
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		for len(records) < line-1 {
+			records = append(records, []string{})
+		}
+		records = append(records, record)
+	}
+}
+
+// headerKeywords guess each mapping column from common bank header names
+var headerKeywords = map[string][]string{
+	"date":      {"txn date", "transaction date", "tran date", "date"},
+	"narration": {"narration", "description", "particulars", "remarks", "details", "transaction details"},
+	"debit":     {"withdrawal amt", "withdrawal amount", "withdrawal", "debit amount", "debit", "dr"},
+	"credit":    {"deposit amt", "deposit amount", "deposit", "credit amount", "credit", "cr"},
+	"amount":    {"amount", "transaction amount"},
+}
+
+// normalizeHeader lowercases a header cell and drops punctuation
+func normalizeHeader(cell string) string {
+	cell = strings.ToLower(strings.TrimSpace(cell))
+	cell = strings.NewReplacer(".", "", "(", " ", ")", " ", "_", " ", "inr", "", "₹", "").Replace(cell)
+	return strings.Join(strings.Fields(cell), " ")
+}
+
+// findHeaderColumn returns the index of the first header matching any keyword
+func findHeaderColumn(header []string, keywords []string) int {
+	for _, keyword := range keywords {
+		for i, cell := range header {
+			if normalizeHeader(cell) == keyword {
+				return i
+			}
+		}
+	}
+	for _, keyword := range keywords {
+		if len(keyword) < 4 {
+			continue // "dr"/"cr" only match exactly
+		}
+		for i, cell := range header {
+			if strings.Contains(normalizeHeader(cell), keyword) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// StatementColumns are the resolved 0-based column indexes (-1 when unused)
+type StatementColumns struct {
+	Header    int
+	Date      int
+	Narration int
+	Debit     int
+	Credit    int
+	Amount    int
+}
+
+// resolveColumn turns a mapping value (header name or 1-based number) into an index
+func resolveColumn(header []string, value string, guess []string) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if guess == nil {
+			return -1
+		}
+		return findHeaderColumn(header, guess)
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		return n - 1
+	}
+	return findHeaderColumn(header, []string{normalizeHeader(value)})
+}
+
+// columnsForHeader resolves a mapping against one candidate header row
+func columnsForHeader(header []string, row int, mapping models.ImportMapping) (StatementColumns, bool, error) {
+	cols := StatementColumns{
+		Header:    row,
+		Date:      resolveColumn(header, mapping.DateColumn, headerKeywords["date"]),
+		Narration: resolveColumn(header, mapping.NarrationColumn, headerKeywords["narration"]),
+		Amount:    -1,
+	}
+	if cols.Date < 0 || cols.Narration < 0 {
+		return cols, false, nil
+	}
+
+	cols.Debit = resolveColumn(header, mapping.DebitColumn, headerKeywords["debit"])
+	cols.Credit = resolveColumn(header, mapping.CreditColumn, headerKeywords["credit"])
+	if cols.Debit < 0 && cols.Credit < 0 {
+		cols.Amount = resolveColumn(header, mapping.AmountColumn, headerKeywords["amount"])
+		if cols.Amount < 0 {
+			return cols, true, errors.New("no debit/credit or amount column found; set them on the mapping")
+		}
+	}
+	return cols, true, nil
+}
+
+// ResolveStatementColumns finds the header row and column positions for a
+// mapping. Columns left empty on the mapping are guessed from the header.
+// A negative HeaderRow means the file has no header and columns are numbers.
+func ResolveStatementColumns(records [][]string, mapping models.ImportMapping) (StatementColumns, error) {
+	if mapping.HeaderRow < 0 {
+		cols, found, err := columnsForHeader(nil, -1, mapping)
+		if err == nil && !found {
+			err = errors.New("files without a header need numbered date and narration columns")
+		}
+		return cols, err
+	}
+
+	candidates := []int{}
+	if mapping.HeaderRow > 0 {
+		candidates = append(candidates, mapping.HeaderRow-1)
+	} else {
+		// Banks put account details above the table; scan the first rows for it
+		for i := 0; i < len(records) && i < 30; i++ {
+			candidates = append(candidates, i)
+		}
+	}
+
+	for _, row := range candidates {
+		if row >= len(records) {
+			break
+		}
+		cols, found, err := columnsForHeader(records[row], row, mapping)
+		if found {
+			return cols, err
+		}
+	}
+	return StatementColumns{Header: -1}, errors.New("no header row with date and narration columns found; set them on the mapping")
+}
+
+// cell returns a trimmed cell or "" when the row is short
+func cell(record []string, index int) string {
+	if index < 0 || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+// StatementParse is the result of parsing a statement file
+type StatementParse struct {
+	Columns      StatementColumns
+	Headers      []string
+	DateFormat   string
+	NumberFormat string
+	Rows         []models.ImportRow
+}
+
+// ParseStatement converts CSV records into rows using a mapping. Lines that
+// aren't transactions (totals, footers) come back with an Error set.
+func ParseStatement(records [][]string, mapping models.ImportMapping) (StatementParse, error) {
+	result := StatementParse{}
+
+	cols, err := ResolveStatementColumns(records, mapping)
+	if err != nil {
+		return result, err
+	}
+	result.Columns = cols
+	if cols.Header >= 0 {
+		result.Headers = records[cols.Header]
+	}
+	body := records[cols.Header+1:]
+
+	var dateSamples, amountSamples []string
+	for _, record := range body {
+		if value := cell(record, cols.Date); value != "" && len(dateSamples) < 50 {
+			if _, err := DetectDateFormat([]string{value}); err == nil {
+				dateSamples = append(dateSamples, value)
+			}
+		}
+		for _, index := range []int{cols.Debit, cols.Credit, cols.Amount} {
+			if value := cell(record, index); value != "" {
+				amountSamples = append(amountSamples, value)
+			}
+		}
+	}
+
+	result.DateFormat = mapping.DateFormat
+	if result.DateFormat == "" {
+		if result.DateFormat, err = DetectDateFormat(dateSamples); err != nil {
+			return result, err
+		}
+	}
+	result.NumberFormat = DetectNumberFormat(amountSamples)
+
+	for i, record := range body {
+		row := models.ImportRow{Row: cols.Header + i + 2, Narration: cell(record, cols.Narration)}
+
+		dateValue := cell(record, cols.Date)
+		if dateValue == "" && row.Narration == "" {
+			continue // Blank spacer line
+		}
+		date, err := time.ParseInLocation(result.DateFormat, dateValue, time.Local)
+		if err != nil {
+			row.Error = "not a transaction row: unreadable date"
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+		row.Date = date
+
+		if cols.Amount >= 0 {
+			amount, err := ParseStatementAmount(cell(record, cols.Amount), result.NumberFormat)
+			if err != nil {
+				row.Error = err.Error()
+			}
+			row.Direction = models.DirectionCredit
+			if amount < 0 {
+				row.Direction = models.DirectionDebit
+				amount = -amount
+			}
+			row.Amount = amount
+		} else {
+			debit, debitErr := ParseStatementAmount(cell(record, cols.Debit), result.NumberFormat)
+			credit, creditErr := ParseStatementAmount(cell(record, cols.Credit), result.NumberFormat)
+			switch {
+			case debitErr != nil:
+				row.Error = debitErr.Error()
+			case creditErr != nil:
+				row.Error = creditErr.Error()
+			case debit != 0 && credit != 0:
+				row.Error = "row has both a debit and a credit"
+			case debit != 0:
+				row.Direction, row.Amount = models.DirectionDebit, debit
+			default:
+				row.Direction, row.Amount = models.DirectionCredit, credit
+			}
+			if row.Amount < 0 {
+				row.Amount = -row.Amount
+			}
+		}
+		if row.Error == "" && row.Amount == 0 {
+			row.Error = "no amount"
+		}
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result, nil
+}
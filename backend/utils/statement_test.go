@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"finance-app-backend/models"
+	"testing"
+	"time"
+)
+
+func TestParseStatementAmount(t *testing.T) {
+	tests := []struct {
+		raw     string
+		format  string
+		want    models.Money
+		wantErr bool
+	}{
+		{raw: "1,00,000.50", format: NumberFormatIndian, want: 10000050},
+		{raw: "100,000.50", format: NumberFormatInternational, want: 10000050},
+		{raw: "100.000,50", format: NumberFormatEuropean, want: 10000050},
+		{raw: "2500", format: NumberFormatPlain, want: 250000},
+		{raw: "₹ 1,250.00", format: NumberFormatIndian, want: 125000},
+		{raw: "Rs.99", format: NumberFormatPlain, want: 9900},
+		{raw: "1,250.00 Dr", format: NumberFormatInternational, want: -125000},
+		{raw: "1,250.00 Cr.", format: NumberFormatInternational, want: 125000},
+		{raw: "(450.00)", format: NumberFormatPlain, want: -45000},
+		{raw: "-450.00", format: NumberFormatPlain, want: -45000},
+		{raw: "450.00-", format: NumberFormatPlain, want: -45000},
+		{raw: "", format: NumberFormatPlain, want: 0},
+		{raw: " - ", format: NumberFormatPlain, want: 0},
+		{raw: "12.345", format: NumberFormatPlain, wantErr: true},
+		{raw: "abc", format: NumberFormatPlain, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseStatementAmount(tt.raw, tt.format)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseStatementAmount(%q, %s) = %s, want an error", tt.raw, tt.format, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseStatementAmount(%q, %s) = %s, %v; want %s", tt.raw, tt.format, got, err, tt.want)
+		}
+	}
+}
+
+func TestDetectNumberFormat(t *testing.T) {
+	tests := []struct {
+		samples []string
+		want    string
+	}{
+		{[]string{"500.00", "1,00,000.00"}, NumberFormatIndian},
+		{[]string{"500.00", "100,000.00"}, NumberFormatInternational},
+		{[]string{"500,00", "1.250,75"}, NumberFormatEuropean},
+		{[]string{"500.00", "12000.00"}, NumberFormatPlain},
+	}
+	for _, tt := range tests {
+		if got := DetectNumberFormat(tt.samples); got != tt.want {
+			t.Errorf("DetectNumberFormat(%v) = %s, want %s", tt.samples, got, tt.want)
+		}
+	}
+}
+
+func TestDetectDateFormat(t *testing.T) {
+	tests := []struct {
+		samples []string
+		want    string
+		wantErr bool
+	}{
+		{samples: []string{"05/10/2025", "28/10/2025"}, want: "02/01/2006"},
+		{samples: []string{"05-Oct-2025", ""}, want: "02-Jan-2006"},
+		{samples: []string{"2025-10-05"}, want: "2006-01-02"},
+		{samples: []string{"10/28/2025"}, want: "01/02/2006"},
+		{samples: []string{"yesterday"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := DetectDateFormat(tt.samples)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("DetectDateFormat(%v) = %q, want an error", tt.samples, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("DetectDateFormat(%v) = %q, %v; want %q", tt.samples, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseStatement(t *testing.T) {
+	data := []byte("\xef\xbb\xbfAccount No: XXXX1234\n" +
+		"Statement for October 2025\n" +
+		"\n" +
+		"Date,Narration,Chq./Ref.No.,Withdrawal Amt.,Deposit Amt.,Closing Balance\n" +
+		"01/10/25,NEFT-ACME CORP-SALARY,N123,,\"1,00,000.00\",\"1,20,000.00\"\n" +
+		"03/10/25,UPI-SWIGGY-swiggy@icici,U456,450.00,,\"1,19,550.00\"\n" +
+		"04/10/25,POS AMAZON,P789,\"1,250.00\",10.00,\"1,18,310.00\"\n" +
+		",,,,,\n" +
+		"Total,,,\"1,700.00\",\"1,00,010.00\",\n")
+
+	records, err := ReadStatementCSV(data)
+	if err != nil {
+		t.Fatalf("ReadStatementCSV returned error %v", err)
+	}
+	parsed, err := ParseStatement(records, models.ImportMapping{})
+	if err != nil {
+		t.Fatalf("ParseStatement returned error %v", err)
+	}
+
+	if parsed.Columns.Header != 3 || parsed.Columns.Date != 0 || parsed.Columns.Narration != 1 ||
+		parsed.Columns.Debit != 3 || parsed.Columns.Credit != 4 {
+		t.Errorf("columns = %+v", parsed.Columns)
+	}
+	if parsed.DateFormat != "02/01/06" {
+		t.Errorf("date format = %q, want 02/01/06", parsed.DateFormat)
+	}
+	if parsed.NumberFormat != NumberFormatIndian {
+		t.Errorf("number format = %q, want %q", parsed.NumberFormat, NumberFormatIndian)
+	}
+
+	want := []struct {
+		row       int
+		direction string
+		amount    models.Money
+		date      time.Time
+		hasError  bool
+	}{
+		{row: 5, direction: models.DirectionCredit, amount: 10000000, date: time.Date(2025, time.October, 1, 0, 0, 0, 0, time.Local)},
+		{row: 6, direction: models.DirectionDebit, amount: 45000, date: time.Date(2025, time.October, 3, 0, 0, 0, 0, time.Local)},
+		{row: 7, hasError: true}, // Both a debit and a credit
+		{row: 9, hasError: true}, // The totals line
+	}
+	if len(parsed.Rows) != len(want) {
+		t.Fatalf("got %d rows %+v, want %d", len(parsed.Rows), parsed.Rows, len(want))
+	}
+	for i, w := range want {
+		row := parsed.Rows[i]
+		if row.Row != w.row {
+			t.Errorf("row %d: line %d, want %d", i, row.Row, w.row)
+		}
+		if w.hasError {
+			if row.Error == "" {
+				t.Errorf("line %d: expected an error", row.Row)
+			}
+			continue
+		}
+		if row.Error != "" || row.Direction != w.direction || row.Amount != w.amount || !row.Date.Equal(w.date) {
+			t.Errorf("line %d = %s %s on %s (error %q), want %s %s on %s", row.Row, row.Direction, row.Amount,
+				row.Date.Format("2006-01-02"), row.Error, w.direction, w.amount, w.date.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestParseStatementSignedAmountColumn(t *testing.T) {
+	records := [][]string{
+		{"2025-10-01", "Coffee", "-120.50"},
+		{"2025-10-02", "Refund", "40"},
+	}
+	mapping := models.ImportMapping{HeaderRow: -1, DateColumn: "1", NarrationColumn: "2", AmountColumn: "3"}
+	parsed, err := ParseStatement(records, mapping)
+	if err != nil {
+		t.Fatalf("ParseStatement returned error %v", err)
+	}
+	if len(parsed.Rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(parsed.Rows))
+	}
+	if row := parsed.Rows[0]; row.Direction != models.DirectionDebit || row.Amount != 12050 || row.Narration != "Coffee" {
+		t.Errorf("first row = %+v", row)
+	}
+	if row := parsed.Rows[1]; row.Direction != models.DirectionCredit || row.Amount != 4000 {
+		t.Errorf("second row = %+v", row)
+	}
+}
@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"errors"
+	"finance-app-backend/models"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TaxCurrency is the currency tax summaries and section limits are in
+const TaxCurrency = "INR"
+
+// TaxSections lists the deduction sections spending can be classified under,
+// with their limits under the old tax regime
+var TaxSections = []models.TaxSection{
+	{Code: "80C", Name: "Investments and premiums",
+		Description: "Life insurance premiums, children's tuition fees, PPF, ELSS, home loan principal",
+		Limit:       15000000},
+	{Code: "80CCD(1B)", Name: "Additional NPS contribution",
+		Description: "Own contribution to NPS over the 80C limit",
+		Limit:       5000000},
+	{Code: "80D", Name: "Health insurance",
+		Description: "Health insurance and preventive check-ups for self, spouse and children",
+		Limit:       2500000, SeniorLimit: 5000000},
+	{Code: "80D-PARENTS", Name: "Parents' health insurance",
+		Description: "Health insurance and preventive check-ups for parents",
+		Limit:       2500000, SeniorLimit: 5000000},
+	{Code: "80E", Name: "Education loan interest",
+		Description: "Interest on a loan for higher education, without a cap"},
+	{Code: "80G", Name: "Donations",
+		Description: "Donations to approved funds and charities; 50% or 100% of each is deductible"},
+	{Code: "80GG", Name: "Rent without HRA",
+		Description: "Rent paid when salary has no house rent allowance",
+		Limit:       6000000},
+}
+
+// ErrUnknownTaxSection is returned for a tax section not in TaxSections
+var ErrUnknownTaxSection = errors.New("unknown tax section")
+
+// taxSectionKey reduces a section code to its letters and digits, so "80ccd 1b"
+// matches "80CCD(1B)"
+func taxSectionKey(code string) string {
+	var key strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			key.WriteRune(r)
+		}
+	}
+	return key.String()
+}
+
+// NormalizeTaxSection returns the canonical code of a tax section, or "" for
+// a blank one
+func NormalizeTaxSection(code string) (string, error) {
+	key := taxSectionKey(code)
+	if key == "" {
+		return "", nil
+	}
+	for _, section := range TaxSections {
+		if taxSectionKey(section.Code) == key {
+			return section.Code, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownTaxSection, strings.TrimSpace(code))
+}
+
+// FinancialYearOf returns the starting year of the April-March financial
+// year a date falls in
+func FinancialYearOf(t time.Time) int {
+	if t.Month() < time.April {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+// ParseFinancialYear reads a financial year written as "2024-25",
+// "2024-2025" or just its starting year "2024"
+func ParseFinancialYear(value string) (int, error) {
+	invalid := errors.New("Invalid financial year, expected e.g. 2024-25")
+	first, second, hasSecond := strings.Cut(strings.TrimSpace(value), "-")
+	start, err := strconv.Atoi(first)
+	if err != nil || len(first) != 4 {
+		return 0, invalid
+	}
+	if hasSecond {
+		end, err := strconv.Atoi(second)
+		if err != nil || (len(second) == 2 && end != (start+1)%100) || (len(second) == 4 && end != start+1) ||
+			(len(second) != 2 && len(second) != 4) {
+			return 0, invalid
+		}
+	}
+	return start, nil
+}
+
+// FinancialYearRange returns the first day of a financial year and the first
+// day after it, in local time
+func FinancialYearRange(start int) (time.Time, time.Time) {
+	from := time.Date(start, time.April, 1, 0, 0, 0, 0, time.Local)
+	return from, from.AddDate(1, 0, 0)
+}
+
+// FinancialYearLabel formats a financial year the way ITR forms do, e.g. 2024-25
+func FinancialYearLabel(start int) string {
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100)
+}
+
+// TaxSummaryCSVHeader is the column layout of tax summary CSV exports
+var TaxSummaryCSVHeader = []string{
+	"financial_year", "section", "name", "limit", "count", "total", "deductible", "remaining", "currency",
+}
+
+// TaxSummaryCSVRecord formats one section of a tax summary as a CSV row
+func TaxSummaryCSVRecord(summary models.TaxSummary, section models.TaxSectionSummary) []string {
+	limit, remaining := "", ""
+	if section.Limit > 0 {
+		limit = section.Limit.String()
+	}
+	if section.Remaining != nil {
+		remaining = section.Remaining.String()
+	}
+	return []string{
+		summary.FinancialYear,
+		section.Code,
+		csvSafe(section.Name),
+		limit,
+		strconv.Itoa(section.Count),
+		section.Total.String(),
+		section.Deductible.String(),
+		remaining,
+		summary.Currency,
+	}
+}
+
+// WriteTaxSummaryPDF renders a financial year's tax summary as a PDF document
+func WriteTaxSummaryPDF(w io.Writer, summary models.TaxSummary) error {
+	l := &reportLayout{pdf: NewPDF()}
+	l.newPage()
+
+	money := func(amount models.Money) string {
+		return FormatReportMoney(amount, summary.Currency)
+	}
+
+	// Title block
+	l.y += 10
+	l.pdf.Text(reportMargin, l.y, 18, true, "CapiFy Tax Deduction Summary")
+	l.pdf.TextRight(reportMargin+reportWidth, l.y, 12, true, "FY "+summary.FinancialYear)
+	l.y += 16
+	l.pdf.Text(reportMargin, l.y, 10, false, summary.UserName)
+	l.pdf.TextRight(reportMargin+reportWidth, l.y, 8, false,
+		"Generated "+summary.GeneratedAt.Format("02 Jan 2006 15:04")+" - amounts in "+summary.Currency)
+	l.y += 10
+	l.pdf.Line(reportMargin, l.y, reportMargin+reportWidth, l.y, 1, 0.2)
+	l.y += 8
+
+	// Summary figures
+	var eligible models.Money
+	used := 0
+	for _, section := range summary.Sections {
+		eligible += section.Total
+		if section.Count > 0 {
+			used++
+		}
+	}
+	figures := []struct{ label, value string }{
+		{"Eligible spending", money(eligible)},
+		{"Deductible", money(summary.TotalDeductible)},
+		{"Sections used", strconv.Itoa(used)},
+	}
+	boxWidth := reportWidth / float64(len(figures))
+	for i, item := range figures {
+		x := reportMargin + float64(i)*boxWidth
+		l.pdf.FillRect(x+2, l.y, boxWidth-4, 40, 0.95, 0.96, 0.98)
+		l.pdf.Text(x+10, l.y+14, 8, false, item.label)
+		l.pdf.Text(x+10, l.y+31, 12, true, FitText(item.value, 12, boxWidth-20))
+	}
+	l.y += 50
+
+	l.heading("Deductions by section")
+	rows := make([][]string, len(summary.Sections))
+	for i, section := range summary.Sections {
+		limit, remaining := "No limit", "-"
+		if section.Limit > 0 {
+			limit = money(section.Limit)
+		}
+		if section.Remaining != nil {
+			remaining = money(*section.Remaining)
+		}
+		rows[i] = []string{section.Code, section.Name, limit, money(section.Total), money(section.Deductible), remaining}
+	}
+	l.table([]reportColumn{
+		{title: "Section", width: 65},
+		{title: "Covers", width: 130},
+		{title: "Limit", width: 80, right: true},
+		{title: "Spent", width: 80, right: true},
+		{title: "Deductible", width: 80, right: true},
+		{title: "Headroom", width: 80, right: true},
+	}, rows)
+	l.note("Limits are those of the old tax regime.")
+	l.note("80G donations are listed in full; apply the 50% or 100% rate of each receipt.")
+	if len(summary.Unconverted) > 0 {
+		l.note(fmt.Sprintf("%d expenses were left out because no exchange rate to %s was available.",
+			len(summary.Unconverted), summary.Currency))
+	}
+
+	l.heading("Expenses")
+	if len(summary.Expenses) == 0 {
+		l.note("No expenses are classified under a tax section this year.")
+	} else {
+		rows := make([][]string, len(summary.Expenses))
+		for i, expense := range summary.Expenses {
+			rows[i] = []string{expense.SpentAt.Format("02 Jan 2006"), expense.Section, expense.Title, expense.Category, money(expense.Amount)}
+		}
+		l.table([]reportColumn{
+			{title: "Date", width: 65},
+			{title: "Section", width: 70},
+			{title: "Description", width: 180},
+			{title: "Category", width: 95},
+			{title: "Amount", width: 105, right: true},
+		}, rows)
+	}
+
+	// Page footers go on last, once the page count is known
+	for i, page := range l.pdf.pages {
+		l.pdf.page = page
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(l.pdf.pages))
+		l.pdf.TextRight(reportMargin+reportWidth, PDFPageHeight-25, 8, false, footer)
+		l.pdf.Text(reportMargin, PDFPageHeight-25, 8, false, "CapiFy - FY "+summary.FinancialYear)
+	}
+
+	_, err := l.pdf.WriteTo(w)
+	return err
+}
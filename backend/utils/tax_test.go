@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseFinancialYear(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "2024-25", want: 2024},
+		{value: "2024-2025", want: 2024},
+		{value: "2024", want: 2024},
+		{value: " 2024-25 ", want: 2024},
+		{value: "2099-00", want: 2099},
+		{value: "2024-26", wantErr: true},
+		{value: "2024-2026", wantErr: true},
+		{value: "2024-5", wantErr: true},
+		{value: "2024-", wantErr: true},
+		{value: "24-25", wantErr: true},
+		{value: "FY2024", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFinancialYear(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseFinancialYear(%q) = %d, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseFinancialYear(%q) = %d, %v; want %d", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestFinancialYearOf(t *testing.T) {
+	tests := []struct {
+		date time.Time
+		want int
+	}{
+		{date: time.Date(2025, time.January, 15, 0, 0, 0, 0, time.Local), want: 2024},
+		{date: time.Date(2025, time.March, 31, 23, 59, 59, 0, time.Local), want: 2024},
+		{date: time.Date(2025, time.April, 1, 0, 0, 0, 0, time.Local), want: 2025},
+		{date: time.Date(2025, time.December, 31, 0, 0, 0, 0, time.Local), want: 2025},
+	}
+	for _, tt := range tests {
+		if got := FinancialYearOf(tt.date); got != tt.want {
+			t.Errorf("FinancialYearOf(%s) = %d, want %d", tt.date.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestFinancialYearRangeAndLabel(t *testing.T) {
+	from, to := FinancialYearRange(2024)
+	if want := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.Local); !from.Equal(want) {
+		t.Errorf("FinancialYearRange(2024) from = %s, want %s", from, want)
+	}
+	if want := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.Local); !to.Equal(want) {
+		t.Errorf("FinancialYearRange(2024) to = %s, want %s", to, want)
+	}
+	if got := FinancialYearOf(to.Add(-time.Nanosecond)); got != 2024 {
+		t.Errorf("last instant of FY 2024-25 falls in %d", got)
+	}
+
+	for start, want := range map[int]string{2024: "2024-25", 2099: "2099-00", 2009: "2009-10"} {
+		if got := FinancialYearLabel(start); got != want {
+			t.Errorf("FinancialYearLabel(%d) = %q, want %q", start, got, want)
+		}
+	}
+}
+
+func TestNormalizeTaxSection(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{code: "80C", want: "80C"},
+		{code: "80c", want: "80C"},
+		{code: "80ccd 1b", want: "80CCD(1B)"},
+		{code: "80CCD(1B)", want: "80CCD(1B)"},
+		{code: "80d-parents", want: "80D-PARENTS"},
+		{code: " 80gg ", want: "80GG"},
+		{code: "", want: ""},
+		{code: "  ", want: ""},
+		{code: "80Z", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeTaxSection(tt.code)
+		if tt.wantErr {
+			if !errors.Is(err, ErrUnknownTaxSection) {
+				t.Errorf("NormalizeTaxSection(%q) = %q, %v; want ErrUnknownTaxSection", tt.code, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeTaxSection(%q) = %q, %v; want %q", tt.code, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif" // Register decoders for receipt uploads
+	"image/jpeg"
+	_ "image/png"
+)
+
+// ThumbnailMaxSize is the longest edge of a generated thumbnail in pixels
+const ThumbnailMaxSize = 320
+
+// maxThumbnailSourcePixels caps the decoded size of an uploaded image (50 MP)
+const maxThumbnailSourcePixels = 50_000_000
+
+// GenerateThumbnail decodes a JPEG, PNG or GIF and returns a JPEG no larger
+// than ThumbnailMaxSize on its longest edge. Each output pixel averages the
+// source pixels it covers, which keeps receipt text legible when shrunk.
+func GenerateThumbnail(data []byte) ([]byte, error) {
+	// Check dimensions before decoding so a tiny file can't claim a huge canvas
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxThumbnailSourcePixels {
+		return nil, errors.New("image is too large to thumbnail")
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	targetW, targetH := width, height
+	if width > ThumbnailMaxSize || height > ThumbnailMaxSize {
+		if width >= height {
+			targetW = ThumbnailMaxSize
+			targetH = max(1, height*ThumbnailMaxSize/width)
+		} else {
+			targetH = ThumbnailMaxSize
+			targetW = max(1, width*ThumbnailMaxSize/height)
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, targetW, targetH))
+	for y := 0; y < targetH; y++ {
+		y0 := bounds.Min.Y + y*height/targetH
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/targetH)
+		for x := 0; x < targetW; x++ {
+			x0 := bounds.Min.X + x*width/targetW
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/targetW)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			// Flatten transparency onto white since JPEG has no alpha
+			white := 0xffff - a/n
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r/n + white), G: uint16(g/n + white), B: uint16(b/n + white), A: 0xffff,
+			})
+		}
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}